@@ -0,0 +1,141 @@
+package admissionreview
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ValidatorChain combines several validators into a single ResourceValidator. All validators are called and the results are merged,
+// i.e. the request is only allowed if all validators allow it and the messages of all denials are joined into a single Status.
+// A validator that returns nil denies the request.
+func ValidatorChain[T any](validators ...ResourceValidator[T]) ResourceValidator[T] {
+	return func(request *T) *ValidateResult {
+		results := make([]*ValidateResult, len(validators))
+		for i, validator := range validators {
+			results[i] = chainResult(validator(request), i)
+		}
+		return mergeValidateResults(results)
+	}
+}
+
+// RequestValidatorChain is the ResourceRequestValidator counterpart of ValidatorChain.
+func RequestValidatorChain[T any](validators ...ResourceRequestValidator[T]) ResourceRequestValidator[T] {
+	return func(meta *RequestMeta, request *T) *ValidateResult {
+		return ValidatorChain(requestValidatorsWithMeta(meta, validators)...)(request)
+	}
+}
+
+// MutaterChain combines several mutaters into a single ResourceMutater. The mutaters are called in sequence, each receiving the
+// response object of the previous mutater. The returned Patch spans from the original request object to the response of the last mutater,
+// so that a single JSON Patch is constructed by the MutatingReviewer. The chain stops at the first mutater that denies the request
+// or returns a nil result.
+// As for every ResourceMutater the mutaters must not modify the received request object but a copy of it.
+func MutaterChain[T any](mutaters ...ResourceMutater[T]) ResourceMutater[T] {
+	return func(request *T) (*ValidateResult, *Patch[T]) {
+		results := make([]*ValidateResult, 0, len(mutaters))
+		response := request
+		for i, mutater := range mutaters {
+			result, patches := mutater(response)
+			result = chainResult(result, i)
+			results = append(results, result)
+			if !result.Allow {
+				return mergeValidateResults(results), nil
+			}
+			if patches != nil && patches.Request != nil && patches.Response != nil {
+				response = patches.Response
+			}
+		}
+		if response == request {
+			return mergeValidateResults(results), nil
+		}
+		return mergeValidateResults(results), &Patch[T]{
+			Request:  request,
+			Response: response,
+		}
+	}
+}
+
+// RequestMutaterChain is the ResourceRequestMutater counterpart of MutaterChain.
+func RequestMutaterChain[T any](mutaters ...ResourceRequestMutater[T]) ResourceRequestMutater[T] {
+	return func(meta *RequestMeta, request *T) (*ValidateResult, *Patch[T]) {
+		return MutaterChain(requestMutatersWithMeta(meta, mutaters)...)(request)
+	}
+}
+
+// requestValidatorsWithMeta binds the meta argument of the validators.
+func requestValidatorsWithMeta[T any](meta *RequestMeta, validators []ResourceRequestValidator[T]) []ResourceValidator[T] {
+	result := make([]ResourceValidator[T], len(validators))
+	for i, validator := range validators {
+		validator := validator
+		result[i] = func(request *T) *ValidateResult {
+			return validator(meta, request)
+		}
+	}
+	return result
+}
+
+// requestMutatersWithMeta binds the meta argument of the mutaters.
+func requestMutatersWithMeta[T any](meta *RequestMeta, mutaters []ResourceRequestMutater[T]) []ResourceMutater[T] {
+	result := make([]ResourceMutater[T], len(mutaters))
+	for i, mutater := range mutaters {
+		mutater := mutater
+		result[i] = func(request *T) (*ValidateResult, *Patch[T]) {
+			return mutater(meta, request)
+		}
+	}
+	return result
+}
+
+// chainResult replaces a missing result of the chain member at the given index with a denial that names the member.
+func chainResult(result *ValidateResult, index int) *ValidateResult {
+	if result != nil {
+		return result
+	}
+	return &ValidateResult{
+		Allow:  false,
+		Status: GetErrorStatus(http.StatusInternalServerError, fmt.Sprintf("chain member %d did not return a result", index), errNoResponse),
+	}
+}
+
+// mergeValidateResults merges the given results. The merged result only allows the request if all results allow it.
+// The messages of all denials are joined into a single failure Status that uses the code of the first denial.
+// Warnings and audit annotations of all results are collected.
+func mergeValidateResults(results []*ValidateResult) *ValidateResult {
+	merged := &ValidateResult{
+		Allow: true,
+	}
+	var messages []string
+	for _, result := range results {
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		for key, value := range result.AuditAnnotations {
+			if merged.AuditAnnotations == nil {
+				merged.AuditAnnotations = make(map[string]string, len(result.AuditAnnotations))
+			}
+			merged.AuditAnnotations[key] = value
+		}
+		if result.Allow {
+			continue
+		}
+		merged.Allow = false
+		if result.Status == nil {
+			continue
+		}
+		if merged.Status == nil {
+			merged.Status = &metav1.Status{
+				Status: metav1.StatusFailure,
+				Reason: result.Status.Reason,
+				Code:   result.Status.Code,
+			}
+		}
+		if result.Status.Message != "" {
+			messages = append(messages, result.Status.Message)
+		}
+	}
+	if merged.Status != nil {
+		merged.Status.Message = strings.Join(messages, "; ")
+	}
+	return merged
+}
@@ -0,0 +1,97 @@
+package admissionreview_test
+
+import (
+	"net/http"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidatorChain(t *testing.T) {
+	allow := func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true, Warnings: []string{"allow"}}
+	}
+	deny := func(message string) admissionreview.ResourceValidator[dataType] {
+		return func(request *dataType) *admissionreview.ValidateResult {
+			return &admissionreview.ValidateResult{
+				Allow:  false,
+				Status: &metav1.Status{Status: metav1.StatusFailure, Message: message, Code: http.StatusUnprocessableEntity},
+			}
+		}
+	}
+
+	reviewer := admissionreview.ValidatingReviewer(admissionreview.ValidatorChain(allow, allow), groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	assert.True(t, testResult.Allowed)
+	assert.Equal(t, []string{"allow", "allow"}, testResult.Warnings)
+
+	reviewer = admissionreview.ValidatingReviewer(admissionreview.ValidatorChain(deny("first"), allow, deny("second")), groupVersionKind)
+	testResult = reviewer.Review(arRequest)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, "first; second", testResult.Result.Message)
+	assert.Equal(t, int32(http.StatusUnprocessableEntity), testResult.Result.Code)
+	assert.Equal(t, []string{"allow"}, testResult.Warnings)
+}
+
+func TestMutaterChain(t *testing.T) {
+	setTest2 := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		response := *request
+		response.Test2 = "234"
+		return &admissionreview.ValidateResult{Allow: true}, &admissionreview.Patch[dataType]{Request: request, Response: &response}
+	}
+	noChange := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		return &admissionreview.ValidateResult{Allow: true}, nil
+	}
+	expectTest2 := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		assert.Equal(t, "234", request.Test2)
+		return &admissionreview.ValidateResult{Allow: true}, nil
+	}
+
+	reviewer := admissionreview.MutatingReviewer(admissionreview.MutaterChain(noChange, setTest2, expectTest2), groupVersionKind)
+	assert.Equal(t, arResponseMutatingSuccess, reviewer.Review(arRequest))
+
+	reviewer = admissionreview.MutatingReviewer(admissionreview.MutaterChain(noChange, noChange), groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	assert.True(t, testResult.Allowed)
+	assert.Nil(t, testResult.Patch)
+}
+
+func TestMutaterChainDenied(t *testing.T) {
+	deny := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		return &admissionreview.ValidateResult{Allow: false, Status: status}, nil
+	}
+	notCalled := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		assert.Fail(t, "mutater after a denial should not be called")
+		return nil, nil
+	}
+	reviewer := admissionreview.MutatingReviewer(admissionreview.MutaterChain(deny, notCalled), groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, status.Message, testResult.Result.Message)
+	assert.Nil(t, testResult.Patch)
+}
+
+func TestChainNilResult(t *testing.T) {
+	allow := func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true}
+	}
+	missing := func(request *dataType) *admissionreview.ValidateResult {
+		return nil
+	}
+	reviewer := admissionreview.ValidatingReviewer(admissionreview.ValidatorChain(allow, missing), groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code)
+	assert.Contains(t, testResult.Result.Message, "chain member 1 did not return a result")
+
+	missingMutater := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		return nil, nil
+	}
+	mutatingReviewer := admissionreview.MutatingReviewer(admissionreview.MutaterChain(missingMutater), groupVersionKind)
+	testResult = mutatingReviewer.Review(arRequest)
+	assert.False(t, testResult.Allowed)
+	assert.Contains(t, testResult.Result.Message, "chain member 0 did not return a result")
+	assert.Nil(t, testResult.Patch)
+}
@@ -0,0 +1,183 @@
+package admissionreview
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// HandlerOption configures optional behaviour of the http.Handler returned by NewHandler.
+type HandlerOption func(*handlerOptions)
+
+// handlerOptions collects the settings applied via the HandlerOption functions.
+type handlerOptions struct {
+	// timeout is the deadline for the review, zero means no deadline apart from the one of the HTTP request context.
+	timeout time.Duration
+	// failurePolicy determines whether the request is allowed (Ignore) or denied (Fail) when the review fails.
+	failurePolicy admissionregistrationv1.FailurePolicyType
+	// logger is the logging backend of the handler and the reviewers called by it.
+	logger Logger
+	// reviewLogLevel is the level of the log event emitted per review.
+	reviewLogLevel LogLevel
+	// reviewLogSampling determines that every n-th review is logged, zero disables the log event.
+	reviewLogSampling uint64
+	// reviewLogCounter counts the reviews for the sampling.
+	reviewLogCounter *atomic.Uint64
+	// metrics records Prometheus metrics about the reviews, nil disables the metrics.
+	metrics *Metrics
+	// tracer creates the spans of the reviews, nil disables tracing.
+	tracer trace.Tracer
+}
+
+// newHandlerOptions applies the given options onto the default settings.
+func newHandlerOptions(opts []HandlerOption) *handlerOptions {
+	options := &handlerOptions{
+		failurePolicy:     admissionregistrationv1.Fail,
+		logger:            defaultLogger,
+		reviewLogLevel:    LogLevelInfo,
+		reviewLogSampling: 1,
+		reviewLogCounter:  &atomic.Uint64{},
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithTimeout sets a deadline for the review. It should be set a bit lower than the timeoutSeconds of the WebhookConfiguration,
+// so that the failure response is received by the API server before it runs into its own timeout.
+func WithTimeout(timeout time.Duration) HandlerOption {
+	return func(options *handlerOptions) {
+		options.timeout = timeout
+	}
+}
+
+// WithFailurePolicy sets whether requests are allowed (Ignore) or denied (Fail) when the review does not finish in time,
+// panics or does not return a result. Defaults to Fail.
+func WithFailurePolicy(failurePolicy admissionregistrationv1.FailurePolicyType) HandlerOption {
+	return func(options *handlerOptions) {
+		options.failurePolicy = failurePolicy
+	}
+}
+
+// WithLogger sets the logging backend of the handler. The logger is passed to the reviewers of this package via the context,
+// e.g. for KindMismatchDenyAndLog. Defaults to the global zerolog logger, use NopLogger to disable logging.
+func WithLogger(logger Logger) HandlerOption {
+	return func(options *handlerOptions) {
+		options.logger = logger
+	}
+}
+
+// WithReviewLogLevel sets the level of the structured log event that is emitted for each review. Defaults to LogLevelInfo.
+// The event contains the UID, GroupVersionKind, namespace, name and operation of the request, the requesting user,
+// the decision including the status code, the number of JSON Patch operations and the latency of the review.
+func WithReviewLogLevel(level LogLevel) HandlerOption {
+	return func(options *handlerOptions) {
+		options.reviewLogLevel = level
+	}
+}
+
+// WithReviewLogSampling restricts the log event of WithReviewLogLevel to every n-th review. Defaults to 1, i.e. every review is logged.
+// Zero disables the log event.
+func WithReviewLogSampling(n uint64) HandlerOption {
+	return func(options *handlerOptions) {
+		options.reviewLogSampling = n
+	}
+}
+
+// WithMetrics records Prometheus metrics about the reviews of the handler, see NewMetrics.
+func WithMetrics(metrics *Metrics) HandlerOption {
+	return func(options *handlerOptions) {
+		options.metrics = metrics
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry tracing of the reviews of the handler via the tracer provider. Each review is traced in a span
+// with the UID, GroupVersionKind and operation of the request as well as the decision. Child spans cover the decoding of the request,
+// the calls of the user functions by the reviewers of this package, the construction of JSON Patches and the encoding of the response.
+func WithTracerProvider(tracerProvider trace.TracerProvider) HandlerOption {
+	return func(options *handlerOptions) {
+		options.tracer = tracerProvider.Tracer(tracerName)
+	}
+}
+
+// handler implements the http.Handler interface for a Reviewer using the given handlerOptions.
+type handler struct {
+	reviewer Reviewer
+	options  *handlerOptions
+}
+
+// NewHandler wraps the reviewer into a http.Handler configured via the given options. See Handle for details.
+func NewHandler(reviewer Reviewer, opts ...HandlerOption) http.Handler {
+	return &handler{
+		reviewer: reviewer,
+		options:  newHandlerOptions(opts),
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle(h.reviewer, h.options, w, r)
+}
+
+// review calls the reviewer with the given context. If the context is done before the reviewer finishes,
+// a response according to the failurePolicy is returned. The reviewer is not interrupted in this case and finishes in the background,
+// ContextReviewer implementations should therefore honour the cancellation of the context.
+// Panics and nil responses of the reviewer are converted into a response according to the failurePolicy as well.
+func review(ctx context.Context, reviewer Reviewer, failurePolicy admissionregistrationv1.FailurePolicyType, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	contextReviewer, ok := reviewer.(ContextReviewer)
+	if !ok {
+		contextReviewer = &contextIgnoringReviewer{reviewer}
+	}
+	ctx = withFailurePolicy(ctx, failurePolicy)
+	responseChan := make(chan *admissionv1.AdmissionResponse, 1)
+	go func() {
+		// safety net, the goroutine is not covered by the recovery of net/http and a panic would terminate the process
+		defer func() {
+			if r := recover(); r != nil {
+				loggerFromContext(ctx).Log(LogLevelError, "Admission review panicked", "uid", requestUID(arRequest), "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+				responseChan <- failureResponse(requestUID(arRequest), failurePolicy,
+					GetErrorStatus(http.StatusInternalServerError, "admission review panicked", fmt.Errorf("%v", r)))
+			}
+		}()
+		responseChan <- safeReview(ctx, arRequest, func() *admissionv1.AdmissionResponse {
+			return contextReviewer.ReviewContext(ctx, arRequest)
+		})
+	}()
+	select {
+	case response := <-responseChan:
+		return response
+	case <-ctx.Done():
+		loggerFromContext(ctx).Log(LogLevelWarn, "Admission review did not finish in time", "uid", requestUID(arRequest), "error", ctx.Err())
+		return timeoutResponse(requestUID(arRequest), failurePolicy, ctx.Err())
+	}
+}
+
+// contextIgnoringReviewer adapts a Reviewer to the ContextReviewer interface by ignoring the context.
+type contextIgnoringReviewer struct {
+	Reviewer
+}
+
+func (reviewer *contextIgnoringReviewer) ReviewContext(_ context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return reviewer.Review(arRequest)
+}
+
+// timeoutResponse constructs the admissionResponse for reviews that did not finish in time according to the failurePolicy.
+func timeoutResponse(uid types.UID, failurePolicy admissionregistrationv1.FailurePolicyType, err error) *admissionv1.AdmissionResponse {
+	return failureResponse(uid, failurePolicy, GetErrorStatus(http.StatusGatewayTimeout, "admission review did not finish in time", err))
+}
+
+// requestUID returns the UID of the admission request, empty if the request is nil.
+func requestUID(arRequest *admissionv1.AdmissionRequest) types.UID {
+	if arRequest == nil {
+		return ""
+	}
+	return arRequest.UID
+}
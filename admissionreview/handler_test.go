@@ -0,0 +1,79 @@
+package admissionreview_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+// blockingReviewer is a ContextReviewer that only returns once its context is done.
+var blockingReviewer = admissionreview.ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	<-ctx.Done()
+	return &admissionv1.AdmissionResponse{UID: arRequest.UID, Allowed: true}
+})
+
+func TestHandlerTimeoutFail(t *testing.T) {
+	handler := admissionreview.NewHandler(blockingReviewer, admissionreview.WithTimeout(time.Millisecond))
+	resp := serveAdmissionReview(t, handler, arRequest)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.False(t, resp.Response.Allowed)
+	require.Equal(t, http.StatusGatewayTimeout, int(resp.Response.Result.Code))
+}
+
+func TestHandlerTimeoutIgnore(t *testing.T) {
+	handler := admissionreview.NewHandler(blockingReviewer, admissionreview.WithTimeout(time.Millisecond),
+		admissionreview.WithFailurePolicy(admissionregistrationv1.Ignore))
+	resp := serveAdmissionReview(t, handler, arRequest)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.True(t, resp.Response.Allowed)
+	require.NotEmpty(t, resp.Response.Warnings)
+}
+
+func TestHandlerInTime(t *testing.T) {
+	reviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true}
+	}, groupVersionKind)
+	handler := admissionreview.NewHandler(reviewer, admissionreview.WithTimeout(time.Minute))
+	resp := serveAdmissionReview(t, handler, arRequest)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.True(t, resp.Response.Allowed)
+}
+
+func TestHandlerMissingRequest(t *testing.T) {
+	handler := admissionreview.NewHandler(admissionreview.MutatingReviewer(func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		return &admissionreview.ValidateResult{Allow: true}, nil
+	}, groupVersionKind))
+	for _, apiVersion := range []string{"admission.k8s.io/v1", "admission.k8s.io/v1beta1"} {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"apiVersion":"`+apiVersion+`","kind":"AdmissionReview"}`))
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	}
+}
+
+// serveAdmissionReview wraps the admission request into an AdmissionReview, calls the handler and returns the deserialized response
+func serveAdmissionReview(t *testing.T, handler http.Handler, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionReview {
+	body, err := json.Marshal(&admissionv1.AdmissionReview{Request: arRequest})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	require.NoError(t, err)
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	defer w.Result().Body.Close()
+	var resp admissionv1.AdmissionReview
+	err = json.NewDecoder(w.Result().Body).Decode(&resp)
+	require.NoError(t, err)
+	return &resp
+}
@@ -2,69 +2,121 @@
 package admissionreview
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/rs/zerolog/log"
 	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	sigsjson "sigs.k8s.io/json"
 )
 
-type httpError struct {
+// HttpError is returned by GetAdmissionReviewFromHttp if the HTTP request does not contain a valid AdmissionReview.
+type HttpError struct {
 	// underlying error
 	Err error
 	// suggested HTTP (error) status code
 	HttpResponseStatus int
 }
 
+func (httpErr *HttpError) Error() string {
+	return httpErr.Err.Error()
+}
+
+func (httpErr *HttpError) Unwrap() error {
+	return httpErr.Err
+}
+
 // Handle receives a Reviewer interface and the ResponseWriter and Request from the http.Handler interface.
 // This covers the IO part as well as error logging, HTTP response code handling and the construction
 // of the AdmissionReview response object.
-// Do not use if you do not wish to use zerolog for logging. GetAdmissionReviewFromHttp is an alternative that
-// provides the relevant IO handling toolings and let the caller handle the HTTP and logging part.
+// Logs via the global zerolog logger, use NewHandler with the WithLogger option for other logging backends.
+// GetAdmissionReviewFromHttp is an alternative that provides the relevant IO handling toolings and let the caller handle the HTTP and logging part.
+// If the reviewer implements the ContextReviewer interface, it receives the context of the HTTP request.
+// Use NewHandler to configure a deadline for the review and the failure policy when the deadline is exceeded.
 func Handle(reviewer Reviewer, w http.ResponseWriter, r *http.Request) {
-	arReview, httpErr := getAdmissionReviewFromHttp(r)
+	handle(reviewer, newHandlerOptions(nil), w, r)
+}
+
+// handle implements Handle using the given handlerOptions.
+func handle(reviewer Reviewer, options *handlerOptions, w http.ResponseWriter, r *http.Request) {
+	ctx := withLogger(r.Context(), options.logger)
+	if options.tracer != nil {
+		ctx = withTracer(ctx, options.tracer)
+	}
+	ctx, span := startSpan(ctx, spanReview)
+	defer span.End()
+
+	_, decodeSpan := startSpan(ctx, spanDecode)
+	arReview, httpErr := GetAdmissionReviewFromHttp(r)
 	if httpErr != nil {
-		log.Error().Err(httpErr.Err).Msg("Error during request parsing")
+		recordError(decodeSpan, httpErr)
+		decodeSpan.End()
+		recordError(span, httpErr)
+		options.logger.Log(LogLevelError, "Error during request parsing", "error", httpErr.Err)
+		options.metrics.observeDecodeError(httpErr)
 		w.WriteHeader(httpErr.HttpResponseStatus)
 		return
 	}
-	response := reviewer.Review(arReview.Request)
+	decodeSpan.End()
+	setRequestAttributes(span, arReview.Request)
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	response := review(ctx, reviewer, options.failurePolicy, arReview.Request)
+	latency := time.Since(start)
+	logReview(options, arReview.Request, response, latency)
+	options.metrics.observeReview(arReview.Request, response, latency)
+	setResponseAttributes(span, response)
 
 	// actually call the admission reviewer and return the response
-	arResponse := admissionv1.AdmissionReview{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "AdmissionReview",
-			APIVersion: "admission.k8s.io/v1",
-		},
-		Response: response,
-	}
+	// the response uses the same API version as the request
+	_, encodeSpan := startSpan(ctx, spanEncode)
+	defer encodeSpan.End()
+	arResponse := NewAdmissionReviewResponse(arReview.APIVersion, response)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(&arResponse)
+	err := json.NewEncoder(w).Encode(arResponse)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to decode response")
+		recordError(encodeSpan, err)
+		options.logger.Log(LogLevelError, "Failed to decode response", "error", err)
 		// try to adjust response, depends on the error details if this has an effect
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
-// getAdmissionReviewFromHttp receives a HTTP request and handles the IO and unmarshal part
-// to extract the AdmissionReview object from it.
-func getAdmissionReviewFromHttp(r *http.Request) (*admissionv1.AdmissionReview, *httpError) {
+// GetAdmissionReviewFromHttp receives a HTTP request and handles the IO and unmarshal part
+// to extract the AdmissionReview object from it. Both admission.k8s.io/v1 and admission.k8s.io/v1beta1 AdmissionReviews
+// are supported, the latter is converted to admission.k8s.io/v1 while the TypeMeta keeps the original API version.
+// Use NewAdmissionReviewResponse to construct the response in the API version of the request.
+// AdmissionReviews without a request are rejected. The returned HttpError contains the suggested HTTP status code for the response.
+func GetAdmissionReviewFromHttp(r *http.Request) (*admissionv1.AdmissionReview, *HttpError) {
 	if r.Method != http.MethodPost {
-		return nil, &httpError{fmt.Errorf("unsupported HTTP method: %v", r.Method), http.StatusMethodNotAllowed}
+		return nil, &HttpError{fmt.Errorf("unsupported HTTP method: %v", r.Method), http.StatusMethodNotAllowed}
 	}
 	if r.Body == nil {
-		return nil, &httpError{errors.New("body missing"), http.StatusBadRequest}
+		return nil, &HttpError{errors.New("body missing"), http.StatusBadRequest}
 	}
-	var arReview admissionv1.AdmissionReview
-	if err := json.NewDecoder(r.Body).Decode(&arReview); err != nil {
-		return nil, &httpError{fmt.Errorf("failed to read and unmarshal body: %w", err), http.StatusBadRequest}
+	rawReview, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, &HttpError{fmt.Errorf("failed to read body: %w", err), http.StatusBadRequest}
+	}
+	arReview, err := unmarshallAdmissionReview(rawReview)
+	if err != nil {
+		return nil, &HttpError{fmt.Errorf("failed to unmarshal body: %w", err), http.StatusBadRequest}
 	}
-	return &arReview, nil
+	if arReview.Request == nil {
+		return nil, &HttpError{errors.New("admission review request missing"), http.StatusBadRequest}
+	}
+	return arReview, nil
 }
 
 // UnmarshallAdmissionRequest checks if the requestGroupVersionKind fits to the provided selector and unmarshalls the raw request into a the result pointer if this is the case.
@@ -75,12 +127,83 @@ func UnmarshallAdmissionRequest[T any](rawRequest []byte, compatibleGroupVersion
 			Allow: true,
 		}
 	}
+	request, _, validateResult = unmarshallObject[T](rawRequest, StrictDecodingOff)
+	return request, validateResult
+}
+
+// UnmarshallAdmissionTransition checks if the GroupVersionKind of the admission request fits to the provided selector and unmarshalls
+// the raw old object as well as the raw object into the result pointers if this is the case.
+// Absent raw objects result in nil pointers, i.e. oldRequest is nil for CREATE and request is nil for DELETE operations.
+// The presence of the validateResult has the same meaning as for UnmarshallAdmissionRequest.
+func UnmarshallAdmissionTransition[T any](arRequest *admissionv1.AdmissionRequest, compatibleGroupVersionKinds []*metav1.GroupVersionKind) (oldRequest *T, request *T, validateResult *ValidateResult) {
+	if !Contains(compatibleGroupVersionKinds, &arRequest.Kind) {
+		return nil, nil, &ValidateResult{
+			Allow: true,
+		}
+	}
+	oldRequest, request, _, validateResult = unmarshallTransition[T](arRequest, StrictDecodingOff)
+	return oldRequest, request, validateResult
+}
+
+// unmarshallTransition unmarshalls the raw old object as well as the raw object of the admission request. Absent raw objects result in nil pointers.
+// The warnings result from strict decoding, see StrictDecodingMode. A returned validateResult implies that unmarshalling failed.
+func unmarshallTransition[T any](arRequest *admissionv1.AdmissionRequest, mode StrictDecodingMode) (oldRequest *T, request *T, warnings []string, validateResult *ValidateResult) {
+	oldRequest, oldWarnings, validateResult := unmarshallOptionalObject[T](arRequest.OldObject.Raw, mode)
+	if validateResult != nil {
+		return nil, nil, nil, validateResult
+	}
+	request, warnings, validateResult = unmarshallOptionalObject[T](arRequest.Object.Raw, mode)
+	if validateResult != nil {
+		return nil, nil, nil, validateResult
+	}
+	return oldRequest, request, append(oldWarnings, warnings...), nil
+}
+
+// unmarshallOptionalObject behaves like unmarshallObject, but returns nil without a validateResult for absent raw objects.
+func unmarshallOptionalObject[T any](rawObject []byte, mode StrictDecodingMode) (*T, []string, *ValidateResult) {
+	if len(rawObject) == 0 {
+		return nil, nil, nil
+	}
+	return unmarshallObject[T](rawObject, mode)
+}
+
+// unmarshallObject unmarshalls the raw object into a new T. The warnings result from strict decoding, see StrictDecodingMode.
+// A returned validateResult implies that unmarshalling failed.
+func unmarshallObject[T any](rawObject []byte, mode StrictDecodingMode) (*T, []string, *ValidateResult) {
 	var result T
-	if err := json.Unmarshal(rawRequest, &result); err != nil {
-		return nil, &ValidateResult{
+	if mode == StrictDecodingOff {
+		if err := json.Unmarshal(rawObject, &result); err != nil {
+			return nil, nil, unmarshallErrorResult(err)
+		}
+		return &result, nil, nil
+	}
+	strictErrs, err := sigsjson.UnmarshalStrict(rawObject, &result)
+	if err != nil {
+		return nil, nil, unmarshallErrorResult(err)
+	}
+	if len(strictErrs) == 0 {
+		return &result, nil, nil
+	}
+	strictErrMessages := make([]string, len(strictErrs))
+	for i, strictErr := range strictErrs {
+		strictErrMessages[i] = strictErr.Error()
+	}
+	if mode == StrictDecodingDeny {
+		return nil, nil, &ValidateResult{
 			Allow:  false,
-			Status: GetErrorStatus(http.StatusUnprocessableEntity, "failed to unmarshal into namespace object", err),
+			Status: GetErrorStatus(http.StatusUnprocessableEntity, "strict decoding failed", errors.New(strings.Join(strictErrMessages, "; "))),
 		}
 	}
-	return &result, nil
+	for i, strictErrMessage := range strictErrMessages {
+		strictErrMessages[i] = "strict decoding: " + strictErrMessage
+	}
+	return &result, strictErrMessages, nil
+}
+
+// unmarshallErrorResult constructs the ValidateResult for objects that could not be unmarshalled.
+func unmarshallErrorResult(err error) *ValidateResult {
+	return &ValidateResult{
+		Allow:  false,
+		Status: GetErrorStatus(http.StatusUnprocessableEntity, "failed to unmarshal into namespace object", err),
+	}
 }
@@ -0,0 +1,105 @@
+package admissionreview
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	// LogLevelDebug is used for detailed information about the processing of admission requests.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is used for regular information.
+	LogLevelInfo
+	// LogLevelWarn is used for denied requests and policy violations that hint at a misconfiguration.
+	LogLevelWarn
+	// LogLevelError is used for failed reviews, e.g. panics or malformed HTTP requests.
+	LogLevelError
+)
+
+// Logger is the logging backend of this package. The keysAndValues are alternating keys and values of structured log fields,
+// the key of errors is "error". Set via the WithLogger option of NewHandler, defaults to the global zerolog logger.
+type Logger interface {
+	Log(level LogLevel, msg string, keysAndValues ...any)
+}
+
+// LoggerFunc is a helper type to use a function as Logger, e.g. as an adapter for other logging libraries.
+type LoggerFunc func(level LogLevel, msg string, keysAndValues ...any)
+
+// Log calls the function.
+func (f LoggerFunc) Log(level LogLevel, msg string, keysAndValues ...any) {
+	f(level, msg, keysAndValues...)
+}
+
+// NopLogger discards all log messages.
+var NopLogger Logger = LoggerFunc(func(LogLevel, string, ...any) {})
+
+// zerologLogger implements the Logger interface for a zerolog.Logger.
+type zerologLogger struct {
+	// logger is used if set, the global zerolog logger otherwise
+	logger *zerolog.Logger
+}
+
+// NewZerologLogger returns a Logger that logs via the given zerolog.Logger.
+func NewZerologLogger(logger zerolog.Logger) Logger {
+	return &zerologLogger{logger: &logger}
+}
+
+// defaultLogger logs via the global zerolog logger at the time of logging, so that later changes of the global logger apply.
+var defaultLogger Logger = &zerologLogger{}
+
+func (l *zerologLogger) Log(level LogLevel, msg string, keysAndValues ...any) {
+	logger := l.logger
+	if logger == nil {
+		logger = &log.Logger
+	}
+	var event *zerolog.Event
+	switch level {
+	case LogLevelDebug:
+		event = logger.Debug()
+	case LogLevelInfo:
+		event = logger.Info()
+	case LogLevelWarn:
+		event = logger.Warn()
+	default:
+		event = logger.Error()
+	}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 == len(keysAndValues) {
+			event = event.Interface(key, nil)
+			break
+		}
+		switch value := keysAndValues[i+1].(type) {
+		case error:
+			event = event.AnErr(key, value)
+		case time.Duration:
+			event = event.Dur(key, value)
+		default:
+			event = event.Interface(key, value)
+		}
+	}
+	event.Msg(msg)
+}
+
+// loggerContextKey is the context key for the Logger of the handler.
+type loggerContextKey struct{}
+
+// withLogger returns a copy of the context that carries the logger.
+func withLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// loggerFromContext returns the logger carried by the context. Defaults to the global zerolog logger.
+func loggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(Logger); ok {
+		return logger
+	}
+	return defaultLogger
+}
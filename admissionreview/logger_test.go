@@ -0,0 +1,69 @@
+package admissionreview_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntry is a log message recorded by the recordingLogger
+type logEntry struct {
+	level         admissionreview.LogLevel
+	msg           string
+	keysAndValues []any
+}
+
+// recordingLogger returns a Logger that appends all log messages to the entries
+func recordingLogger(entries *[]logEntry) admissionreview.Logger {
+	return admissionreview.LoggerFunc(func(level admissionreview.LogLevel, msg string, keysAndValues ...any) {
+		*entries = append(*entries, logEntry{level: level, msg: msg, keysAndValues: keysAndValues})
+	})
+}
+
+func TestHandlerWithLogger(t *testing.T) {
+	otherGroupVersionKind := *groupVersionKind
+	otherGroupVersionKind.Kind = "Pod"
+	reviewer := admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+		admissionreview.WithKinds(&otherGroupVersionKind), admissionreview.WithKindMismatchPolicy(admissionreview.KindMismatchDenyAndLog))
+
+	var entries []logEntry
+	handler := admissionreview.NewHandler(reviewer, admissionreview.WithLogger(recordingLogger(&entries)), admissionreview.WithReviewLogSampling(0))
+	resp := serveAdmissionReview(t, handler, arRequestUpdate)
+	assert.False(t, resp.Response.Allowed)
+	require.Len(t, entries, 1)
+	assert.Equal(t, admissionreview.LogLevelWarn, entries[0].level)
+	assert.Equal(t, []any{"uid", arRequestUpdate.UID}, entries[0].keysAndValues[:2])
+
+	w := serveRawAdmissionReview(t, handler, "invalid")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, entries, 2)
+	assert.Equal(t, admissionreview.LogLevelError, entries[1].level)
+}
+
+func TestZerologLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := admissionreview.NewZerologLogger(zerolog.New(&buf))
+	logger.Log(admissionreview.LogLevelWarn, "test", "uid", "123", "error", errors.New("failed"), "code", 500)
+	assert.JSONEq(t, `{"level":"warn","message":"test","uid":"123","error":"failed","code":500}`, buf.String())
+}
+
+func TestGetAdmissionReviewFromHttp(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	_, httpErr := admissionreview.GetAdmissionReviewFromHttp(r)
+	require.NotNil(t, httpErr)
+	assert.Equal(t, http.StatusMethodNotAllowed, httpErr.HttpResponseStatus)
+
+	r, err = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","request":{"uid":"123"}}`))
+	require.NoError(t, err)
+	arReview, httpErr := admissionreview.GetAdmissionReviewFromHttp(r)
+	require.Nil(t, httpErr)
+	assert.Equal(t, "123", string(arReview.Request.UID))
+}
@@ -0,0 +1,96 @@
+package admissionreview
+
+import (
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Wildcard matches any value of a GroupVersionKind or GroupVersionResource field in the Matcher implementations of this package.
+const Wildcard = "*"
+
+// Matcher decides whether an admission request is in scope of a reviewer.
+type Matcher interface {
+	Match(*admissionv1.AdmissionRequest) bool
+}
+
+// MatcherFunc is a helper type to use a function as Matcher.
+type MatcherFunc func(*admissionv1.AdmissionRequest) bool
+
+func (matcher MatcherFunc) Match(arRequest *admissionv1.AdmissionRequest) bool {
+	return matcher(arRequest)
+}
+
+// MatchKinds matches admission requests whose Kind is contained in the given GroupVersionKinds.
+// Each field of the given GroupVersionKinds may be set to the Wildcard, e.g. to match regardless of the version.
+func MatchKinds(groupVersionKinds ...*metav1.GroupVersionKind) Matcher {
+	return MatcherFunc(func(arRequest *admissionv1.AdmissionRequest) bool {
+		return containsGroupVersionKind(groupVersionKinds, &arRequest.Kind)
+	})
+}
+
+// MatchRequestKinds behaves like MatchKinds, but matches against the RequestKind of the admission request, i.e. the kind
+// of the original API request before a conversion due to matchPolicy Equivalent. Falls back to Kind if RequestKind is absent.
+func MatchRequestKinds(groupVersionKinds ...*metav1.GroupVersionKind) Matcher {
+	return MatcherFunc(func(arRequest *admissionv1.AdmissionRequest) bool {
+		if arRequest.RequestKind == nil {
+			return containsGroupVersionKind(groupVersionKinds, &arRequest.Kind)
+		}
+		return containsGroupVersionKind(groupVersionKinds, arRequest.RequestKind)
+	})
+}
+
+// MatchResources matches admission requests whose Resource is contained in the given GroupVersionResources.
+// Each field of the given GroupVersionResources may be set to the Wildcard.
+func MatchResources(groupVersionResources ...*metav1.GroupVersionResource) Matcher {
+	return MatcherFunc(func(arRequest *admissionv1.AdmissionRequest) bool {
+		return containsGroupVersionResource(groupVersionResources, &arRequest.Resource)
+	})
+}
+
+// MatchRequestResources behaves like MatchResources, but matches against the RequestResource of the admission request.
+// Falls back to Resource if RequestResource is absent.
+func MatchRequestResources(groupVersionResources ...*metav1.GroupVersionResource) Matcher {
+	return MatcherFunc(func(arRequest *admissionv1.AdmissionRequest) bool {
+		if arRequest.RequestResource == nil {
+			return containsGroupVersionResource(groupVersionResources, &arRequest.Resource)
+		}
+		return containsGroupVersionResource(groupVersionResources, arRequest.RequestResource)
+	})
+}
+
+// MatchAny matches admission requests that are matched by at least one of the given matchers.
+func MatchAny(matchers ...Matcher) Matcher {
+	return MatcherFunc(func(arRequest *admissionv1.AdmissionRequest) bool {
+		for _, matcher := range matchers {
+			if matcher.Match(arRequest) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// containsGroupVersionKind checks if the obj argument is matched by one of the slice elements taking the Wildcard into account
+func containsGroupVersionKind(slice []*metav1.GroupVersionKind, obj *metav1.GroupVersionKind) bool {
+	for _, el := range slice {
+		if matchField(el.Group, obj.Group) && matchField(el.Version, obj.Version) && matchField(el.Kind, obj.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsGroupVersionResource checks if the obj argument is matched by one of the slice elements taking the Wildcard into account
+func containsGroupVersionResource(slice []*metav1.GroupVersionResource, obj *metav1.GroupVersionResource) bool {
+	for _, el := range slice {
+		if matchField(el.Group, obj.Group) && matchField(el.Version, obj.Version) && matchField(el.Resource, obj.Resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchField checks if the value is matched by the pattern, which is either the Wildcard or has to be equal to the value
+func matchField(pattern string, value string) bool {
+	return pattern == Wildcard || pattern == value
+}
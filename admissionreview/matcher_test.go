@@ -0,0 +1,54 @@
+package admissionreview_test
+
+import (
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var arRequestConverted = &admissionv1.AdmissionRequest{
+	UID:             "123",
+	Kind:            metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+	RequestKind:     &metav1.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"},
+	Resource:        metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+	RequestResource: &metav1.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "deployments"},
+}
+
+func TestMatchKinds(t *testing.T) {
+	assert.True(t, admissionreview.MatchKinds(&metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}).Match(arRequestConverted))
+	assert.True(t, admissionreview.MatchKinds(&metav1.GroupVersionKind{Group: "apps", Version: "*", Kind: "Deployment"}).Match(arRequestConverted))
+	assert.True(t, admissionreview.MatchKinds(&metav1.GroupVersionKind{Group: "*", Version: "*", Kind: "*"}).Match(arRequestConverted))
+	assert.False(t, admissionreview.MatchKinds(&metav1.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"}).Match(arRequestConverted))
+	assert.False(t, admissionreview.MatchKinds(&metav1.GroupVersionKind{Group: "", Version: "*", Kind: "Deployment"}).Match(arRequestConverted))
+	assert.False(t, admissionreview.MatchKinds().Match(arRequestConverted))
+}
+
+func TestMatchRequestKinds(t *testing.T) {
+	assert.True(t, admissionreview.MatchRequestKinds(&metav1.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"}).Match(arRequestConverted))
+	assert.False(t, admissionreview.MatchRequestKinds(&metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}).Match(arRequestConverted))
+	assert.True(t, admissionreview.MatchRequestKinds(groupVersionKind).Match(arRequest))
+}
+
+func TestMatchResources(t *testing.T) {
+	assert.True(t, admissionreview.MatchResources(&metav1.GroupVersionResource{Group: "apps", Version: "*", Resource: "deployments"}).Match(arRequestConverted))
+	assert.False(t, admissionreview.MatchResources(&metav1.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "deployments"}).Match(arRequestConverted))
+	assert.True(t, admissionreview.MatchRequestResources(&metav1.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "deployments"}).Match(arRequestConverted))
+}
+
+func TestMatchAny(t *testing.T) {
+	matcher := admissionreview.MatchAny(admissionreview.MatchKinds(groupVersionKind),
+		admissionreview.MatchResources(&metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "*"}))
+	assert.True(t, matcher.Match(arRequest))
+	assert.True(t, matcher.Match(arRequestConverted))
+	assert.False(t, admissionreview.MatchAny().Match(arRequest))
+}
+
+func TestWithMatcher(t *testing.T) {
+	reviewer := admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+		admissionreview.WithMatcher(admissionreview.MatchKinds(&metav1.GroupVersionKind{Group: "", Version: "*", Kind: "Namespace"})))
+	assert.Equal(t, arResponseFailure, reviewer.Review(arRequestUpdate))
+	assert.True(t, reviewer.Review(arRequestConverted).Allowed)
+}
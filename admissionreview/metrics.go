@@ -0,0 +1,96 @@
+package admissionreview
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// Metrics collects Prometheus metrics about the admission reviews of handlers, see WithMetrics.
+// A single Metrics instance can be shared between several handlers.
+type Metrics struct {
+	// reviews counts the reviews by GroupVersionKind, operation and decision
+	reviews *prometheus.CounterVec
+	// denials counts the denied reviews by GroupVersionKind, operation and status code
+	denials *prometheus.CounterVec
+	// patchOperations observes the number of JSON Patch operations of mutating reviews
+	patchOperations *prometheus.HistogramVec
+	// decodeErrors counts the HTTP requests that did not contain a valid AdmissionReview by the HTTP status code of the response
+	decodeErrors *prometheus.CounterVec
+	// duration observes the latency of the reviews
+	duration *prometheus.HistogramVec
+}
+
+// reviewLabels are the labels of the metrics that relate to a single admission request.
+var reviewLabels = []string{"group", "version", "kind", "operation"}
+
+// NewMetrics creates the admission review metrics and registers them at the registerer, e.g. prometheus.DefaultRegisterer.
+// The metrics are exposed via the HTTP handler of the corresponding gatherer, e.g. promhttp.Handler() for the default registry.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	metrics := &Metrics{
+		reviews: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "admission",
+			Name:      "reviews_total",
+			Help:      "Number of admission reviews by GroupVersionKind, operation and decision (allowed or denied).",
+		}, append(reviewLabels, "decision")),
+		denials: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "admission",
+			Name:      "review_denials_total",
+			Help:      "Number of denied admission reviews by GroupVersionKind, operation and status code.",
+		}, append(reviewLabels, "code")),
+		patchOperations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "admission",
+			Name:      "review_patch_operations",
+			Help:      "Number of JSON Patch operations of allowed admission reviews.",
+			Buckets:   []float64{0, 1, 2, 5, 10, 20, 50, 100},
+		}, reviewLabels),
+		decodeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "admission",
+			Name:      "review_decode_errors_total",
+			Help:      "Number of HTTP requests without a valid AdmissionReview by the HTTP status code of the response.",
+		}, []string{"code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "admission",
+			Name:      "review_duration_seconds",
+			Help:      "Latency of the admission reviews by GroupVersionKind and operation.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, reviewLabels),
+	}
+	for _, collector := range []prometheus.Collector{metrics.reviews, metrics.denials, metrics.patchOperations, metrics.decodeErrors, metrics.duration} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// observeReview records the metrics for the review of the admission request. A nil Metrics is valid and records nothing.
+func (metrics *Metrics) observeReview(arRequest *admissionv1.AdmissionRequest, response *admissionv1.AdmissionResponse, latency time.Duration) {
+	if metrics == nil || arRequest == nil || response == nil {
+		return
+	}
+	labels := prometheus.Labels{
+		"group":     arRequest.Kind.Group,
+		"version":   arRequest.Kind.Version,
+		"kind":      arRequest.Kind.Kind,
+		"operation": string(arRequest.Operation),
+	}
+	metrics.duration.With(labels).Observe(latency.Seconds())
+	if response.Allowed {
+		metrics.reviews.MustCurryWith(labels).WithLabelValues("allowed").Inc()
+		metrics.patchOperations.With(labels).Observe(float64(patchOperationCount(response.Patch)))
+		return
+	}
+	metrics.reviews.MustCurryWith(labels).WithLabelValues("denied").Inc()
+	metrics.denials.MustCurryWith(labels).WithLabelValues(strconv.Itoa(int(responseCode(response)))).Inc()
+}
+
+// observeDecodeError records an HTTP request without a valid AdmissionReview. A nil Metrics is valid and records nothing.
+func (metrics *Metrics) observeDecodeError(httpErr *HttpError) {
+	if metrics == nil {
+		return
+	}
+	metrics.decodeErrors.WithLabelValues(strconv.Itoa(httpErr.HttpResponseStatus)).Inc()
+}
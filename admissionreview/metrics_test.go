@@ -0,0 +1,66 @@
+package admissionreview_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandlerMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := admissionreview.NewMetrics(registry)
+	require.NoError(t, err)
+
+	arRequestPod := &admissionv1.AdmissionRequest{
+		UID:       "123",
+		Kind:      *podGroupVersionKind,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: podRaw},
+	}
+	mutatingReviewer := admissionreview.BuilderMutatingReviewer(func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().SetLabel(request, "tier", "backend")
+	}, admissionreview.WithKinds(podGroupVersionKind))
+	handler := admissionreview.NewHandler(mutatingReviewer, admissionreview.WithMetrics(metrics), admissionreview.WithLogger(admissionreview.NopLogger))
+	serveAdmissionReview(t, handler, arRequestPod)
+	serveAdmissionReview(t, handler, arRequestPod)
+	w := serveRawAdmissionReview(t, handler, "invalid")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	validatingReviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: false, Status: status}
+	}, groupVersionKind)
+	handler = admissionreview.NewHandler(validatingReviewer, admissionreview.WithMetrics(metrics), admissionreview.WithLogger(admissionreview.NopLogger))
+	serveAdmissionReview(t, handler, arRequest)
+
+	expected := `
+# HELP admission_reviews_total Number of admission reviews by GroupVersionKind, operation and decision (allowed or denied).
+# TYPE admission_reviews_total counter
+admission_reviews_total{decision="allowed",group="",kind="Pod",operation="CREATE",version="v1"} 2
+admission_reviews_total{decision="denied",group="",kind="Namespace",operation="",version="v1"} 1
+# HELP admission_review_denials_total Number of denied admission reviews by GroupVersionKind, operation and status code.
+# TYPE admission_review_denials_total counter
+admission_review_denials_total{code="403",group="",kind="Namespace",operation="",version="v1"} 1
+# HELP admission_review_decode_errors_total Number of HTTP requests without a valid AdmissionReview by the HTTP status code of the response.
+# TYPE admission_review_decode_errors_total counter
+admission_review_decode_errors_total{code="400"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"admission_reviews_total", "admission_review_denials_total", "admission_review_decode_errors_total"))
+	assert.Equal(t, 2, mustGatherAndCount(t, registry, "admission_review_duration_seconds"))
+}
+
+// mustGatherAndCount returns the number of series of the metric in the registry
+func mustGatherAndCount(t *testing.T, registry *prometheus.Registry, metricName string) int {
+	count, err := testutil.GatherAndCount(registry, metricName)
+	require.NoError(t, err)
+	return count
+}
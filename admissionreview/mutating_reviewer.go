@@ -1,6 +1,7 @@
 package admissionreview
 
 import (
+	"context"
 	"encoding/json"
 	"k8s.io/apimachinery/pkg/types"
 	"net/http"
@@ -15,6 +16,7 @@ var jsonPatchType = admissionv1.PatchTypeJSONPatch
 // Patch is used to construct the relevant JSON Patch operations.
 type Patch[T any] struct {
 	// Request is the unmarshalled original request object. Returning nil here will yield an empty JSON patch response.
+	// The changes from Request to Response are applied onto the raw request object, so fields unknown to T are preserved.
 	Request *T
 	// Response is the modified request object. Returning nil here will yield an empty JSON patch response.
 	Response *T
@@ -26,38 +28,61 @@ type Patch[T any] struct {
 type ResourceMutater[T any] func(request *T) (*ValidateResult, *Patch[T])
 
 // MutatingReviewer is the implementation of the ReviewerHandler interface. Checks the GroupVersionKind of the receives request
-// against what the given reviewer.Modifier supports. A miss match will result in a non-modifying response and
-// the allow value set to the value given by reviewer.AllowOnModifierMiss.
-// Otherwise the Patch function of the Modifier interface is called, a JSON Patch is constructed from the result
-// and wrapped into an admissionResponse.
+// against the compatibleGroupVersionKinds. A miss match will result in a non-modifying response that allows the request.
+// Otherwise the mutater is called, a JSON Patch is constructed from the result and wrapped into an admissionResponse.
+// Use RequestMutatingReviewer with the WithKindMismatchPolicy option to deny requests for incompatible kinds instead.
 func MutatingReviewer[T any](mutater ResourceMutater[T], compatibleGroupVersionKinds ...*metav1.GroupVersionKind) ReviewerHandler {
-	return ReviewFunc(func(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		_, span := startSpan(ctx, spanDecodeObject)
 		request, skipMutate := UnmarshallAdmissionRequest[T](arRequest.Object.Raw, compatibleGroupVersionKinds, &arRequest.Kind)
+		span.End()
 		if skipMutate != nil {
 			return skipMutate.admissionResponse(arRequest.UID)
 		}
+		_, span = startSpan(ctx, spanReviewFunction)
 		result, patches := mutater(request)
-		if !result.Allow || patches == nil {
-			return result.admissionResponse(arRequest.UID)
-		}
-
-		// collect changes into JSON Patch
-		patch, err := jsondiff.Compare(patches.Request, patches.Response)
-		if err != nil {
-			return jsonPatchErrorResponse(arRequest.UID, err)
-		}
-		patchJson, err := json.Marshal(&patch)
-		if err != nil {
-			return jsonMarshallErrorResponse(arRequest.UID, err)
-		}
-		// everything has worked, construct response
-		response := result.admissionResponse(arRequest.UID)
-		response.Patch = patchJson
-		response.PatchType = &jsonPatchType
-		return response
+		span.End()
+		return patchResponse(ctx, arRequest.UID, arRequest.Object.Raw, defaultReviewerOptions, result, patches, nil)
 	})
 }
 
+// patchResponse constructs the JSON Patch from the given patches relative to the raw request object and wraps it together with the result into an admissionResponse.
+// The remutate function reinvokes the mutater for the idempotency check, see checkPatch.
+func patchResponse[T any](ctx context.Context, uid types.UID, rawObject []byte, options *reviewerOptions, result *ValidateResult, patches *Patch[T], remutate remutateFunc) *admissionv1.AdmissionResponse {
+	if result == nil || !result.Allow || patches == nil {
+		return result.admissionResponse(uid)
+	}
+	ctx, span := startSpan(ctx, spanPatchDiff)
+	defer span.End()
+
+	// collect changes into JSON Patch
+	patch, err := rawPatch(rawObject, patches, options.diffOptions)
+	if err != nil {
+		return jsonPatchErrorResponse(uid, err)
+	}
+	return jsonPatchResponse(ctx, uid, rawObject, options, result, patch, remutate)
+}
+
+// jsonPatchResponse checks the JSON Patch according to the options and wraps it together with the result into an admissionResponse.
+func jsonPatchResponse(ctx context.Context, uid types.UID, rawObject []byte, options *reviewerOptions, result *ValidateResult, patch jsondiff.Patch, remutate remutateFunc) *admissionv1.AdmissionResponse {
+	patch, patchFailure := checkPatch(ctx, uid, rawObject, options, patch, remutate)
+	if patchFailure != nil {
+		return patchFailure.admissionResponse(uid)
+	}
+	if len(patch) == 0 {
+		return result.admissionResponse(uid)
+	}
+	patchJson, err := json.Marshal(&patch)
+	if err != nil {
+		return jsonMarshallErrorResponse(uid, err)
+	}
+	// everything has worked, construct response
+	response := result.admissionResponse(uid)
+	response.Patch = patchJson
+	response.PatchType = &jsonPatchType
+	return response
+}
+
 func jsonPatchErrorResponse(uid types.UID, err error) *admissionv1.AdmissionResponse {
 	return &admissionv1.AdmissionResponse{
 		UID:     uid,
@@ -42,3 +42,23 @@ func TestMutatingReviewAllowed(t *testing.T) {
 	testResult := reviewer.Review(arRequest)
 	assert.Equal(t, arResponseMutatingSuccess, testResult)
 }
+
+func TestMutatingReviewWarnings(t *testing.T) {
+	warnings := []string{"test2 has been defaulted"}
+	resourceMutaterMock := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		response := *request
+		response.Test2 = "234"
+		return &admissionreview.ValidateResult{
+				Allow:    true,
+				Warnings: warnings,
+			}, &admissionreview.Patch[dataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+
+	reviewer := admissionreview.MutatingReviewer(resourceMutaterMock, groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	assert.Equal(t, warnings, testResult.Warnings)
+	assert.Equal(t, dataPatch, testResult.Patch)
+}
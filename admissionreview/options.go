@@ -0,0 +1,238 @@
+package admissionreview
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/wI2L/jsondiff"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KindMismatchPolicy determines the response for admission requests whose GroupVersionKind is not compatible with the reviewer,
+// i.e. that are not matched by any of its matchers.
+type KindMismatchPolicy int
+
+const (
+	// KindMismatchAllow allows requests for incompatible kinds without calling the review function.
+	KindMismatchAllow KindMismatchPolicy = iota
+	// KindMismatchDeny denies requests for incompatible kinds with a BadRequest status.
+	KindMismatchDeny
+	// KindMismatchDenyAndLog denies requests for incompatible kinds like KindMismatchDeny and additionally logs a warning.
+	KindMismatchDenyAndLog
+)
+
+// StrictDecodingMode determines how unknown and duplicate fields in the objects of the admission request are handled.
+// Unknown fields are silently dropped when unmarshalling into the Go type otherwise, hence they are neither validated nor seen by mutaters.
+type StrictDecodingMode int
+
+const (
+	// StrictDecodingOff unmarshalls the objects without strict checks.
+	StrictDecodingOff StrictDecodingMode = iota
+	// StrictDecodingWarn adds a warning to the admission response for each unknown or duplicate field.
+	StrictDecodingWarn
+	// StrictDecodingDeny denies requests with unknown or duplicate fields with an UnprocessableEntity status.
+	StrictDecodingDeny
+)
+
+// ReviewerOption configures optional behaviour of the reviewers that accept options.
+type ReviewerOption func(*reviewerOptions)
+
+// reviewerOptions collects the settings applied via the ReviewerOption functions.
+type reviewerOptions struct {
+	// matchers select the admission requests the reviewer handles. A request is handled if at least one matcher matches,
+	// all requests are handled without matchers.
+	matchers []Matcher
+	// kindMismatchPolicy determines the response for requests that are not matched.
+	kindMismatchPolicy KindMismatchPolicy
+	// strictDecoding determines how unknown and duplicate fields are handled.
+	strictDecoding StrictDecodingMode
+	// testOperations determines whether replace and remove operations of the JSON Patch are guarded by test operations.
+	testOperations bool
+	// idempotencyCheck determines whether the JSON Patch is checked to be idempotent.
+	idempotencyCheck bool
+	// diffOptions are passed to jsondiff when the JSON Patch is computed from a Patch.
+	diffOptions []jsondiff.Option
+	// forbiddenOperations are the JSON Patch operations mutaters must not produce.
+	forbiddenOperations []ForbiddenOperation
+	// pathScope restricts the paths mutaters may modify.
+	pathScope pathScope
+	// operations are the operations the reviewer handles, empty means all operations.
+	operations []admissionv1.Operation
+	// subResources are the subresources the reviewer handles, empty means all subresources including the main resource.
+	subResources []string
+}
+
+// defaultReviewerOptions are used by the reviewers that do not accept options.
+var defaultReviewerOptions = newReviewerOptions(nil)
+
+// newReviewerOptions applies the given options onto the default settings.
+func newReviewerOptions(opts []ReviewerOption) *reviewerOptions {
+	options := &reviewerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithKinds adds GroupVersionKinds the reviewer is compatible with. Requests for other kinds are allowed without calling the review function.
+// Shorthand for WithMatcher(MatchKinds(compatibleGroupVersionKinds...)), hence the fields may be set to the Wildcard.
+// Without WithKinds or WithMatcher the reviewer handles requests of all kinds, which are denied if they cannot be unmarshalled.
+func WithKinds(compatibleGroupVersionKinds ...*metav1.GroupVersionKind) ReviewerOption {
+	return WithMatcher(MatchKinds(compatibleGroupVersionKinds...))
+}
+
+// WithMatcher adds a Matcher that selects admission requests the reviewer is compatible with. If several matchers are given,
+// a request is compatible if at least one of them matches. Requests that are not compatible are handled according to the KindMismatchPolicy.
+func WithMatcher(matcher Matcher) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.matchers = append(options.matchers, matcher)
+	}
+}
+
+// WithKindMismatchPolicy sets the response for requests that are not matched by any of the matchers of the reviewer.
+// Defaults to KindMismatchAllow. Security-sensitive webhooks should deny, so that a misconfigured WebhookConfiguration fails closed.
+func WithKindMismatchPolicy(policy KindMismatchPolicy) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.kindMismatchPolicy = policy
+	}
+}
+
+// WithStrictDecoding sets how unknown and duplicate fields in the objects of the admission request are handled.
+// Strict decoding is case-sensitive regarding the field names and defaults to StrictDecodingOff.
+func WithStrictDecoding(mode StrictDecodingMode) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.strictDecoding = mode
+	}
+}
+
+// WithTestOperations prefixes the JSON Patch of mutating reviewers with test operations for the original values of all paths
+// that are replaced or removed. Hence, the patch fails instead of applying blindly if the object has been changed concurrently,
+// e.g. by another mutating webhook during reinvocation.
+func WithTestOperations() ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.testOperations = true
+	}
+}
+
+// WithIdempotencyCheck lets mutating reviewers invoke the mutater a second time on the patched object, as the API server does for
+// the reinvocationPolicy IfNeeded, and check that the second invocation does not change the object anymore.
+// Mutations that are not idempotent, e.g. appending to a list unconditionally, are denied with an InternalServerError status.
+// The mutater is called twice per request, hence it must be free of side effects.
+func WithIdempotencyCheck() ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.idempotencyCheck = true
+	}
+}
+
+// WithDiffOptions sets the jsondiff options used to compute the JSON Patch between the Request and Response of a Patch,
+// e.g. jsondiff.Factorize(), jsondiff.Rationalize() or jsondiff.Invertible(). Has no effect on patches constructed via a PatchBuilder.
+func WithDiffOptions(diffOptions ...jsondiff.Option) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.diffOptions = append(options.diffOptions, diffOptions...)
+	}
+}
+
+// WithForbiddenOperations rejects mutations whose JSON Patch contains one of the forbidden operations with an InternalServerError status.
+// The policy is checked for all mutating reviewers, regardless of how the JSON Patch has been constructed.
+func WithForbiddenOperations(forbidden ...ForbiddenOperation) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.forbiddenOperations = append(options.forbiddenOperations, forbidden...)
+	}
+}
+
+// WithAllowedPaths restricts mutating reviewers to JSON Patch operations on the given JSON pointer paths and paths nested below them.
+// Each path token may be the Wildcard, e.g. "/metadata/labels/*" allows setting single labels but not creating the labels map.
+// Defaults to all paths. Operations outside of the scope are handled according to WithPathScopeViolationPolicy.
+func WithAllowedPaths(paths ...string) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.pathScope.allowed = append(options.pathScope.allowed, paths...)
+	}
+}
+
+// WithDeniedPaths forbids mutating reviewers JSON Patch operations on the given JSON pointer paths, paths nested below them and their parents,
+// as e.g. replacing "/metadata" also modifies "/metadata/labels". Takes precedence over WithAllowedPaths and supports the Wildcard in the same way.
+// Trailing slashes are ignored, tokens are compared unescaped according to RFC 6901, e.g. "/metadata/labels/app.kubernetes.io~1name".
+// Operations outside of the scope are handled according to WithPathScopeViolationPolicy.
+func WithDeniedPaths(paths ...string) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.pathScope.denied = append(options.pathScope.denied, paths...)
+	}
+}
+
+// WithPathScopeViolationPolicy sets how operations outside of the path scope are handled. Defaults to PathScopeViolationDeny.
+func WithPathScopeViolationPolicy(policy PathScopeViolationPolicy) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.pathScope.violationPolicy = policy
+	}
+}
+
+// WithOperations restricts the reviewer to the given operations. Requests for other operations are allowed without calling the review function.
+// Defaults to all operations.
+func WithOperations(operations ...admissionv1.Operation) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.operations = append(options.operations, operations...)
+	}
+}
+
+// WithSubResources restricts the reviewer to the given subresources, e.g. "status" or "scale". The empty string selects the main resource.
+// Requests for other subresources are allowed without calling the review function. Defaults to all subresources including the main resource.
+func WithSubResources(subResources ...string) ReviewerOption {
+	return func(options *reviewerOptions) {
+		options.subResources = append(options.subResources, subResources...)
+	}
+}
+
+// skip checks whether the admission request is out of scope for the reviewer.
+// The presence of the validateResult implies that the review function should not be called and the validateResult used instead.
+func (options *reviewerOptions) skip(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *ValidateResult {
+	if len(options.matchers) != 0 && !MatchAny(options.matchers...).Match(arRequest) {
+		return kindMismatchResult(ctx, options.kindMismatchPolicy, arRequest)
+	}
+	if !containsOrEmpty(options.operations, arRequest.Operation) ||
+		!containsOrEmpty(options.subResources, arRequest.SubResource) {
+		return &ValidateResult{
+			Allow: true,
+		}
+	}
+	return nil
+}
+
+// kindMismatchResult constructs the ValidateResult for requests with an incompatible GroupVersionKind according to the policy.
+func kindMismatchResult(ctx context.Context, policy KindMismatchPolicy, arRequest *admissionv1.AdmissionRequest) *ValidateResult {
+	if policy == KindMismatchAllow {
+		return &ValidateResult{
+			Allow: true,
+		}
+	}
+	err := fmt.Errorf("kind %s is not handled by this webhook", arRequest.Kind.String())
+	if policy == KindMismatchDenyAndLog {
+		loggerFromContext(ctx).Log(LogLevelWarn, "Denied admission request, check the rules of the WebhookConfiguration", "uid", arRequest.UID, "error", err)
+	}
+	return &ValidateResult{
+		Allow:  false,
+		Status: GetErrorStatus(http.StatusBadRequest, "incompatible admission request", err),
+	}
+}
+
+// containsOrEmpty checks if the obj argument is contained in the slice argument or if the slice is empty
+func containsOrEmpty[T comparable](slice []T, obj T) bool {
+	if len(slice) == 0 {
+		return true
+	}
+	for _, el := range slice {
+		if el == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// withWarnings prepends the given warnings to the ones of the admission response.
+func withWarnings(response *admissionv1.AdmissionResponse, warnings []string) *admissionv1.AdmissionResponse {
+	if len(warnings) != 0 && response != nil {
+		response.Warnings = append(warnings, response.Warnings...)
+	}
+	return response
+}
@@ -0,0 +1,89 @@
+package admissionreview_test
+
+import (
+	"net/http"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+func TestOptionsOperations(t *testing.T) {
+	reviewer := admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+		admissionreview.WithKinds(groupVersionKind), admissionreview.WithOperations(admissionv1.Create))
+	assert.True(t, reviewer.Review(arRequestUpdate).Allowed)
+
+	reviewer = admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+		admissionreview.WithKinds(groupVersionKind), admissionreview.WithOperations(admissionv1.Create, admissionv1.Update))
+	assert.Equal(t, arResponseFailure, reviewer.Review(arRequestUpdate))
+}
+
+func TestOptionsSubResources(t *testing.T) {
+	arRequestStatus := arRequestUpdate.DeepCopy()
+	arRequestStatus.SubResource = "status"
+
+	reviewer := admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+		admissionreview.WithKinds(groupVersionKind), admissionreview.WithSubResources(""))
+	assert.True(t, reviewer.Review(arRequestStatus).Allowed)
+	assert.Equal(t, arResponseFailure, reviewer.Review(arRequestUpdate))
+
+	reviewer = admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+		admissionreview.WithKinds(groupVersionKind), admissionreview.WithSubResources("status"))
+	assert.Equal(t, arResponseFailure, reviewer.Review(arRequestStatus))
+	assert.True(t, reviewer.Review(arRequestUpdate).Allowed)
+}
+
+// denyingTransitionValidator denies all requests with the shared failure status
+func denyingTransitionValidator(oldRequest *dataType, request *dataType) *admissionreview.ValidateResult {
+	return &admissionreview.ValidateResult{
+		Allow:  false,
+		Status: status,
+	}
+}
+
+func TestOptionsKindMismatchPolicy(t *testing.T) {
+	otherGroupVersionKind := *groupVersionKind
+	otherGroupVersionKind.Kind = "Pod"
+
+	reviewer := admissionreview.TransitionValidatingReviewer(denyingTransitionValidator, admissionreview.WithKinds(&otherGroupVersionKind))
+	assert.True(t, reviewer.Review(arRequestUpdate).Allowed)
+
+	for _, policy := range []admissionreview.KindMismatchPolicy{admissionreview.KindMismatchDeny, admissionreview.KindMismatchDenyAndLog} {
+		reviewer = admissionreview.TransitionValidatingReviewer(denyingTransitionValidator,
+			admissionreview.WithKinds(&otherGroupVersionKind), admissionreview.WithKindMismatchPolicy(policy))
+		testResult := reviewer.Review(arRequestUpdate)
+		assert.False(t, testResult.Allowed)
+		assert.Equal(t, arRequestUpdate.UID, testResult.UID)
+		assert.Equal(t, int32(http.StatusBadRequest), testResult.Result.Code)
+	}
+}
+
+func TestOptionsStrictDecoding(t *testing.T) {
+	arRequestUnknownField := arRequest.DeepCopy()
+	arRequestUnknownField.Object.Raw = []byte("{\"test\":\"123\",\"unknown\":\"abc\"}")
+	allowingValidator := func(meta *admissionreview.RequestMeta, request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true}
+	}
+
+	reviewer := admissionreview.RequestValidatingReviewer(allowingValidator, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequestUnknownField)
+	assert.True(t, testResult.Allowed)
+	assert.Empty(t, testResult.Warnings)
+
+	reviewer = admissionreview.RequestValidatingReviewer(allowingValidator, admissionreview.WithKinds(groupVersionKind),
+		admissionreview.WithStrictDecoding(admissionreview.StrictDecodingWarn))
+	testResult = reviewer.Review(arRequestUnknownField)
+	assert.True(t, testResult.Allowed)
+	assert.Len(t, testResult.Warnings, 1)
+	assert.Contains(t, testResult.Warnings[0], "unknown")
+	assert.Empty(t, reviewer.Review(arRequest).Warnings)
+
+	reviewer = admissionreview.RequestValidatingReviewer(allowingValidator, admissionreview.WithKinds(groupVersionKind),
+		admissionreview.WithStrictDecoding(admissionreview.StrictDecodingDeny))
+	testResult = reviewer.Review(arRequestUnknownField)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, int32(http.StatusUnprocessableEntity), testResult.Result.Code)
+	assert.Contains(t, testResult.Result.Message, "unknown")
+	assert.True(t, reviewer.Review(arRequest).Allowed)
+}
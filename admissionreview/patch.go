@@ -0,0 +1,434 @@
+package admissionreview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/wI2L/jsondiff"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// patchApplyOptions are lenient regarding missing paths, as the raw request object may lack fields that are present in the marshalled Go type.
+var patchApplyOptions = func() *jsonpatch.ApplyOptions {
+	options := jsonpatch.NewApplyOptions()
+	options.AllowMissingPathOnRemove = true
+	options.EnsurePathExistsOnAdd = true
+	return options
+}()
+
+// rawPatch constructs the JSON Patch of the changes between the Request and Response of the patches relative to the raw request object.
+// The changes are merged onto the raw request object, see mergeRaw, and the raw object is diffed against the merged one.
+// This ensures that fields unknown to the Go type or normalised by marshalling are neither removed nor replaced, also for shifted array elements.
+// Falls back to the patch between the marshalled Go types if the raw request object is absent.
+// The patch is empty if the Request or Response is nil. The diffOptions apply to the returned patch.
+func rawPatch[T any](rawObject []byte, patches *Patch[T], diffOptions []jsondiff.Option) (jsondiff.Patch, error) {
+	if patches.Request == nil || patches.Response == nil {
+		return nil, nil
+	}
+	if len(rawObject) == 0 {
+		return jsondiff.Compare(patches.Request, patches.Response, diffOptions...)
+	}
+	raw, err := decodeJson(rawObject)
+	if err != nil {
+		return nil, err
+	}
+	request, err := marshalToJsonValue(patches.Request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := marshalToJsonValue(patches.Response)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(request, response) {
+		return nil, nil
+	}
+	merged, err := mergeRaw(raw, request, response, "")
+	if err != nil {
+		return nil, err
+	}
+	mergedObject, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return jsondiff.CompareJSON(rawObject, mergedObject, diffOptions...)
+}
+
+// mergeRaw applies the changes from the request to the response value onto the raw value, which the request value has been unmarshalled from.
+// Unchanged values keep their raw content. Object members absent in the response are removed, members of the raw object that are
+// absent in the request are kept. Array elements of the response are mapped onto the request elements (see matchArrayElements)
+// and merged with the corresponding raw elements, new elements are taken from the response.
+// The values are unmarshalled JSON documents, path is the JSON pointer of the values for error messages.
+func mergeRaw(raw any, request any, response any, path string) (any, error) {
+	if reflect.DeepEqual(request, response) {
+		return raw, nil
+	}
+	switch responseValue := response.(type) {
+	case map[string]any:
+		requestValue, requestOk := request.(map[string]any)
+		rawValue, rawOk := raw.(map[string]any)
+		if !requestOk || !rawOk {
+			return response, nil
+		}
+		merged := make(map[string]any, len(rawValue))
+		for key, value := range rawValue {
+			if _, ok := responseValue[key]; ok || !hasKey(requestValue, key) {
+				merged[key] = value
+			}
+		}
+		for key, value := range responseValue {
+			requestElement, inRequest := requestValue[key]
+			rawElement, inRaw := rawValue[key]
+			switch {
+			case inRequest && reflect.DeepEqual(requestElement, value):
+				// unchanged, absent raw members are normalised by marshalling and stay absent
+			case inRequest && inRaw:
+				mergedElement, err := mergeRaw(rawElement, requestElement, value, path+"/"+EscapePointerToken(key))
+				if err != nil {
+					return nil, err
+				}
+				merged[key] = mergedElement
+			default:
+				merged[key] = value
+			}
+		}
+		return merged, nil
+	case []any:
+		requestValue, requestOk := request.([]any)
+		rawValue, rawOk := raw.([]any)
+		if !requestOk || !rawOk {
+			return response, nil
+		}
+		if len(requestValue) != len(rawValue) {
+			return nil, fmt.Errorf("array %s of the Go type does not correspond to the raw object", path)
+		}
+		mapping := matchArrayElements(requestValue, responseValue)
+		merged := make([]any, len(responseValue))
+		for i, value := range responseValue {
+			if mapping[i] < 0 {
+				merged[i] = value
+				continue
+			}
+			mergedElement, err := mergeRaw(rawValue[mapping[i]], requestValue[mapping[i]], value, path+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			merged[i] = mergedElement
+		}
+		return merged, nil
+	default:
+		return response, nil
+	}
+}
+
+// matchArrayElements maps each response element onto the index of the request element it originates from, -1 for new elements.
+// Each request element is used at most once. Elements are matched in the following order of precedence: equal elements at the same index,
+// equal elements at any index, objects with the same "name" member (the merge key of most Kubernetes lists) and finally the remaining
+// elements in their order, i.e. modified elements keep their raw content even if other elements have been inserted or removed.
+func matchArrayElements(request []any, response []any) []int {
+	mapping := make([]int, len(response))
+	used := make([]bool, len(request))
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	match := func(matches func(requestIndex int, responseIndex int) bool) {
+		for i := range response {
+			if mapping[i] >= 0 {
+				continue
+			}
+			for j := range request {
+				if !used[j] && matches(j, i) {
+					mapping[i], used[j] = j, true
+					break
+				}
+			}
+		}
+	}
+	match(func(requestIndex int, responseIndex int) bool {
+		return requestIndex == responseIndex && reflect.DeepEqual(request[requestIndex], response[responseIndex])
+	})
+	match(func(requestIndex int, responseIndex int) bool {
+		return reflect.DeepEqual(request[requestIndex], response[responseIndex])
+	})
+	match(func(requestIndex int, responseIndex int) bool {
+		requestName, ok := elementName(request[requestIndex])
+		responseName, responseOk := elementName(response[responseIndex])
+		return ok && responseOk && requestName == responseName
+	})
+	match(func(requestIndex int, responseIndex int) bool {
+		return true
+	})
+	return mapping
+}
+
+// elementName returns the string valued "name" member of an object element.
+func elementName(element any) (string, bool) {
+	object, ok := element.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	name, ok := object["name"].(string)
+	return name, ok
+}
+
+// hasKey checks whether the object has the member.
+func hasKey(object map[string]any, key string) bool {
+	_, ok := object[key]
+	return ok
+}
+
+// decodeJson unmarshalls the JSON document. Numbers are kept as json.Number to preserve their raw representation.
+func decodeJson(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var result any
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// marshalToJsonValue converts the value into its unmarshalled JSON document representation, see decodeJson.
+func marshalToJsonValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJson(data)
+}
+
+// ForbiddenOperation describes JSON Patch operations that mutaters must not produce, see WithForbiddenOperations.
+type ForbiddenOperation struct {
+	// Path is a JSON pointer, the operations are forbidden for the path itself, all nested paths and its parents, e.g. "/status".
+	// Supports the Wildcard in the same way as WithDeniedPaths.
+	Path string
+	// Types are the forbidden operation types, e.g. jsondiff.OperationRemove. Empty means all types except test.
+	// The source path of move operations is treated as removed.
+	Types []string
+}
+
+// forbids checks whether the operation is forbidden.
+func (forbidden *ForbiddenOperation) forbids(op *jsondiff.Operation) bool {
+	if op.Type == jsondiff.OperationMove && forbidden.forbidsType(jsondiff.OperationRemove) && overlapsPath(forbidden.Path, op.From) {
+		return true
+	}
+	return forbidden.forbidsType(op.Type) && overlapsPath(forbidden.Path, op.Path)
+}
+
+// forbidsType checks whether the operation type is forbidden.
+func (forbidden *ForbiddenOperation) forbidsType(opType string) bool {
+	if len(forbidden.Types) == 0 && opType == jsondiff.OperationTest {
+		return false
+	}
+	return containsOrEmpty(forbidden.Types, opType)
+}
+
+// checkForbiddenOperations returns an error for the first operation of the patch that is forbidden.
+func checkForbiddenOperations(forbiddenOperations []ForbiddenOperation, patch jsondiff.Patch) error {
+	for i := range patch {
+		for j := range forbiddenOperations {
+			if forbiddenOperations[j].forbids(&patch[i]) {
+				return fmt.Errorf("operation %s is forbidden for path %s", patch[i].String(), forbiddenOperations[j].Path)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPatch applies the patch policy and safety settings of the options onto the JSON Patch relative to the raw request object.
+// A returned validateResult implies that the patch has been rejected.
+// The remutate function is required for the idempotency check and may be nil otherwise.
+func checkPatch(ctx context.Context, uid types.UID, rawObject []byte, options *reviewerOptions, patch jsondiff.Patch, remutate remutateFunc) (jsondiff.Patch, *ValidateResult) {
+	if err := checkForbiddenOperations(options.forbiddenOperations, patch); err != nil {
+		return nil, &ValidateResult{
+			Allow:  false,
+			Status: GetErrorStatus(http.StatusInternalServerError, "mutation violates the patch policy", err),
+		}
+	}
+	patch, scopeFailure := applyPathScope(ctx, uid, &options.pathScope, patch)
+	if scopeFailure != nil {
+		return nil, scopeFailure
+	}
+	if len(patch) == 0 || len(rawObject) == 0 || (!options.testOperations && !options.idempotencyCheck) {
+		return patch, nil
+	}
+	if options.idempotencyCheck && remutate != nil {
+		if err := checkIdempotency(rawObject, patch, remutate); err != nil {
+			return nil, &ValidateResult{
+				Allow:  false,
+				Status: GetErrorStatus(http.StatusInternalServerError, "mutation is not idempotent", err),
+			}
+		}
+	}
+	if options.testOperations {
+		var err error
+		patch, err = withTestOperations(rawObject, patch)
+		if err != nil {
+			return nil, &ValidateResult{
+				Allow:  false,
+				Status: GetErrorStatus(http.StatusInternalServerError, "failed to add test operations to JSON patch", err),
+			}
+		}
+	}
+	return patch, nil
+}
+
+// remutateFunc invokes the mutater again on the patched object and returns its JSON Patch relative to the patched object, see WithIdempotencyCheck.
+type remutateFunc func(patchedObject []byte) (jsondiff.Patch, error)
+
+// patchRemutater returns the remutateFunc for mutaters that return a Patch.
+func patchRemutater[T any](mutate func(request *T) (*ValidateResult, *Patch[T])) remutateFunc {
+	return func(patchedObject []byte) (jsondiff.Patch, error) {
+		request, err := unmarshallPatchedObject[T](patchedObject)
+		if err != nil {
+			return nil, err
+		}
+		result, patches := mutate(request)
+		if err = checkReinvocationResult(result); err != nil || patches == nil {
+			return nil, err
+		}
+		return rawPatch(patchedObject, patches, nil)
+	}
+}
+
+// builderRemutater returns the remutateFunc for mutaters that return a PatchBuilder.
+func builderRemutater[T any](mutate func(request *T) (*ValidateResult, *PatchBuilder)) remutateFunc {
+	return func(patchedObject []byte) (jsondiff.Patch, error) {
+		request, err := unmarshallPatchedObject[T](patchedObject)
+		if err != nil {
+			return nil, err
+		}
+		result, builder := mutate(request)
+		if err = checkReinvocationResult(result); err != nil || builder == nil {
+			return nil, err
+		}
+		return builder.Patch(), nil
+	}
+}
+
+// unmarshallPatchedObject unmarshalls the patched object for the reinvocation of the mutater.
+func unmarshallPatchedObject[T any](patchedObject []byte) (*T, error) {
+	request, _, validateResult := unmarshallObject[T](patchedObject, StrictDecodingOff)
+	if validateResult != nil {
+		return nil, errors.New(validateResult.Status.Message)
+	}
+	return request, nil
+}
+
+// checkReinvocationResult checks that the reinvoked mutater allows the patched object.
+func checkReinvocationResult(result *ValidateResult) error {
+	if result == nil {
+		return errNoResponse
+	}
+	if !result.Allow {
+		return errors.New("mutater denies the patched object")
+	}
+	return nil
+}
+
+// checkIdempotency applies the patch onto the raw object and invokes the mutater again on the patched object, as the API server does
+// for the reinvocationPolicy IfNeeded. The mutation is idempotent if the JSON Patch of the reinvocation does not change the patched object.
+func checkIdempotency(rawObject []byte, patch jsondiff.Patch, remutate remutateFunc) error {
+	patchedObject, err := applyJsonPatch(rawObject, patch)
+	if err != nil {
+		return fmt.Errorf("failed to apply patch: %w", err)
+	}
+	reinvocationPatch, err := remutate(patchedObject)
+	if err != nil {
+		return fmt.Errorf("failed to reinvoke the mutater on the patched object: %w", err)
+	}
+	if len(reinvocationPatch) == 0 {
+		return nil
+	}
+	repatchedObject, err := applyJsonPatch(patchedObject, reinvocationPatch)
+	if err != nil {
+		return fmt.Errorf("failed to apply the patch of the reinvocation: %w", err)
+	}
+	diff, err := jsondiff.CompareJSON(patchedObject, repatchedObject)
+	if err != nil {
+		return err
+	}
+	if len(diff) != 0 {
+		return fmt.Errorf("reinvoking the mutater on the patched object changes it: %s", diff.String())
+	}
+	return nil
+}
+
+// applyJsonPatch applies the patch onto the raw object.
+func applyJsonPatch(rawObject []byte, patch jsondiff.Patch) ([]byte, error) {
+	patchJson, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	decodedPatch, err := jsonpatch.DecodePatch(patchJson)
+	if err != nil {
+		return nil, err
+	}
+	return decodedPatch.ApplyWithOptions(rawObject, patchApplyOptions)
+}
+
+// withTestOperations prefixes the patch with test operations for the original values of all paths that are replaced or removed.
+// Paths that are not present in the raw object are skipped.
+func withTestOperations(rawObject []byte, patch jsondiff.Patch) (jsondiff.Patch, error) {
+	var doc any
+	if err := json.Unmarshal(rawObject, &doc); err != nil {
+		return nil, err
+	}
+	var tests jsondiff.Patch
+	for _, op := range patch {
+		if op.Type != jsondiff.OperationReplace && op.Type != jsondiff.OperationRemove {
+			continue
+		}
+		value, err := lookupPointer(doc, op.Path)
+		if errors.Is(err, errPointerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, jsondiff.Operation{Type: jsondiff.OperationTest, Path: op.Path, Value: value})
+	}
+	return append(tests, patch...), nil
+}
+
+// errPointerNotFound is returned by lookupPointer if the path is not present in the document.
+var errPointerNotFound = errors.New("JSON pointer not found")
+
+// lookupPointer resolves the RFC 6901 JSON pointer in the unmarshalled JSON document.
+func lookupPointer(doc any, pointer string) (any, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %s", pointer)
+	}
+	current := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescapePointerToken(token)
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, errPointerNotFound
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, errPointerNotFound
+			}
+			current = node[index]
+		default:
+			return nil, errPointerNotFound
+		}
+	}
+	return current, nil
+}
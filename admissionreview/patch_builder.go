@@ -0,0 +1,185 @@
+package admissionreview
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/wI2L/jsondiff"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PatchBuilder constructs RFC 6902 JSON Patch operations directly, as an alternative to the diffing of Patch[T].
+// The higher-level methods receive the current state of the object to decide between adding and replacing values.
+// The received objects are not modified, the builder keeps track of the lists and maps it has created.
+// All paths are JSON pointers, use EscapePointerToken for single path elements.
+type PatchBuilder struct {
+	patch jsondiff.Patch
+	// created holds the paths of maps and lists that have been added by the builder
+	created map[string]bool
+}
+
+// NewPatchBuilder returns a PatchBuilder without operations.
+func NewPatchBuilder() *PatchBuilder {
+	return &PatchBuilder{
+		created: make(map[string]bool),
+	}
+}
+
+// Add appends an add operation for the path, which sets object members and inserts into lists. Returns the builder for chaining.
+func (builder *PatchBuilder) Add(path string, value any) *PatchBuilder {
+	builder.patch = append(builder.patch, jsondiff.Operation{Type: jsondiff.OperationAdd, Path: path, Value: value})
+	return builder
+}
+
+// Replace appends a replace operation for the path, which has to exist. Returns the builder for chaining.
+func (builder *PatchBuilder) Replace(path string, value any) *PatchBuilder {
+	builder.patch = append(builder.patch, jsondiff.Operation{Type: jsondiff.OperationReplace, Path: path, Value: value})
+	return builder
+}
+
+// Remove appends a remove operation for the path, which has to exist. Returns the builder for chaining.
+func (builder *PatchBuilder) Remove(path string) *PatchBuilder {
+	builder.patch = append(builder.patch, jsondiff.Operation{Type: jsondiff.OperationRemove, Path: path})
+	return builder
+}
+
+// Test appends a test operation, the whole patch is rejected if the value at the path does not equal the given value. Returns the builder for chaining.
+func (builder *PatchBuilder) Test(path string, value any) *PatchBuilder {
+	builder.patch = append(builder.patch, jsondiff.Operation{Type: jsondiff.OperationTest, Path: path, Value: value})
+	return builder
+}
+
+// SetLabel sets the label of the object. Returns the builder for chaining.
+func (builder *PatchBuilder) SetLabel(obj metav1.Object, key string, value string) *PatchBuilder {
+	return builder.setMapEntry("/metadata/labels", obj.GetLabels() != nil, key, value)
+}
+
+// SetAnnotation sets the annotation of the object. Returns the builder for chaining.
+func (builder *PatchBuilder) SetAnnotation(obj metav1.Object, key string, value string) *PatchBuilder {
+	return builder.setMapEntry("/metadata/annotations", obj.GetAnnotations() != nil, key, value)
+}
+
+// AppendContainer appends the container to the pod spec located at podSpecPath, e.g. "/spec" for Pods
+// or "/spec/template/spec" for Deployments. Returns the builder for chaining.
+func (builder *PatchBuilder) AppendContainer(podSpecPath string, podSpec *corev1.PodSpec, container corev1.Container) *PatchBuilder {
+	return builder.appendListEntry(podSpecPath+"/containers", podSpec.Containers != nil, container)
+}
+
+// AddToleration appends the toleration to the pod spec located at podSpecPath if it is not already present. Returns the builder for chaining.
+func (builder *PatchBuilder) AddToleration(podSpecPath string, podSpec *corev1.PodSpec, toleration corev1.Toleration) *PatchBuilder {
+	for _, present := range podSpec.Tolerations {
+		if present.MatchToleration(&toleration) && equalTolerationSeconds(present.TolerationSeconds, toleration.TolerationSeconds) {
+			return builder
+		}
+	}
+	return builder.appendListEntry(podSpecPath+"/tolerations", podSpec.Tolerations != nil, toleration)
+}
+
+// EnsureEnvVar ensures that all containers of the pod spec located at podSpecPath have the environment variable set to the given value.
+// Present environment variables with the same name are replaced. Returns the builder for chaining.
+func (builder *PatchBuilder) EnsureEnvVar(podSpecPath string, podSpec *corev1.PodSpec, envVar corev1.EnvVar) *PatchBuilder {
+	for i, container := range podSpec.Containers {
+		envPath := podSpecPath + "/containers/" + strconv.Itoa(i) + "/env"
+		index := -1
+		for j, present := range container.Env {
+			if present.Name == envVar.Name {
+				index = j
+				break
+			}
+		}
+		switch {
+		case index < 0:
+			builder.appendListEntry(envPath, container.Env != nil, envVar)
+		case !equalEnvVar(&container.Env[index], &envVar):
+			builder.Replace(envPath+"/"+strconv.Itoa(index), envVar)
+		}
+	}
+	return builder
+}
+
+// Len returns the number of operations.
+func (builder *PatchBuilder) Len() int {
+	return len(builder.patch)
+}
+
+// Patch returns the constructed operations.
+func (builder *PatchBuilder) Patch() jsondiff.Patch {
+	return builder.patch
+}
+
+// setMapEntry sets the entry of the map located at mapPath, the map is created if it is not present.
+func (builder *PatchBuilder) setMapEntry(mapPath string, present bool, key string, value any) *PatchBuilder {
+	if !present && !builder.created[mapPath] {
+		builder.created[mapPath] = true
+		return builder.Add(mapPath, map[string]any{key: value})
+	}
+	return builder.Add(mapPath+"/"+EscapePointerToken(key), value)
+}
+
+// appendListEntry appends the value to the list located at listPath, the list is created if it is not present.
+func (builder *PatchBuilder) appendListEntry(listPath string, present bool, value any) *PatchBuilder {
+	if !present && !builder.created[listPath] {
+		builder.created[listPath] = true
+		return builder.Add(listPath, []any{value})
+	}
+	return builder.Add(listPath+"/-", value)
+}
+
+// equalEnvVar checks whether the environment variables are equal by comparing their JSON representation
+func equalEnvVar(a *corev1.EnvVar, b *corev1.EnvVar) bool {
+	aJson, errA := json.Marshal(a)
+	bJson, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJson) == string(bJson)
+}
+
+// equalTolerationSeconds compares the values of the toleration seconds, nil is only equal to nil.
+func equalTolerationSeconds(a *int64, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// EscapePointerToken escapes a single element of a JSON pointer path according to RFC 6901, e.g. label keys that contain a slash.
+func EscapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// ResourceBuilderMutater receives the metadata of the admission request alongside the unmarshalled request object and returns
+// the JSON Patch operations via a PatchBuilder. The PatchBuilder might be nil if no mutation is necessary.
+type ResourceBuilderMutater[T any] func(meta *RequestMeta, request *T) (*ValidateResult, *PatchBuilder)
+
+// BuilderMutatingReviewer is the implementation of the ReviewerHandler interface for mutaters that construct the JSON Patch
+// operations directly via a PatchBuilder instead of diffing the request and response objects. Requests that are not selected
+// via the WithKinds or WithMatcher options are handled without calling the mutater, see WithKindMismatchPolicy.
+func BuilderMutatingReviewer[T any](mutater ResourceBuilderMutater[T], opts ...ReviewerOption) ReviewerHandler {
+	options := newReviewerOptions(opts)
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if skipMutate := options.skip(ctx, arRequest); skipMutate != nil {
+			return skipMutate.admissionResponse(arRequest.UID)
+		}
+		_, span := startSpan(ctx, spanDecodeObject)
+		request, warnings, skipMutate := unmarshallOptionalObject[T](arRequest.Object.Raw, options.strictDecoding)
+		span.End()
+		if skipMutate != nil {
+			return skipMutate.admissionResponse(arRequest.UID)
+		}
+		_, span = startSpan(ctx, spanReviewFunction)
+		meta := NewRequestMeta(arRequest)
+		result, builder := mutater(meta, request)
+		span.End()
+		if result == nil || !result.Allow || builder == nil || builder.Len() == 0 {
+			return withWarnings(result.admissionResponse(arRequest.UID), warnings)
+		}
+		ctx, span = startSpan(ctx, spanPatchDiff)
+		defer span.End()
+		remutate := builderRemutater(func(request *T) (*ValidateResult, *PatchBuilder) {
+			return mutater(meta, request)
+		})
+		return withWarnings(jsonPatchResponse(ctx, arRequest.UID, arRequest.Object.Raw, options, result, builder.Patch(), remutate), warnings)
+	})
+}
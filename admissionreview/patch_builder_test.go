@@ -0,0 +1,106 @@
+package admissionreview_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var podGroupVersionKind = &metav1.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "Pod",
+}
+
+var podRaw = []byte(`{"metadata":{"name":"test","annotations":{"a":"b"}},"spec":{"containers":[{"name":"app","image":"app","env":[{"name":"MODE","value":"dev"}]},{"name":"sidecar","image":"sidecar"}]}}`)
+
+func TestPatchBuilder(t *testing.T) {
+	var pod corev1.Pod
+	require.NoError(t, json.Unmarshal(podRaw, &pod))
+	builder := admissionreview.NewPatchBuilder().
+		SetLabel(&pod, "kubernetes.io/name", "test").
+		SetLabel(&pod, "tier", "backend").
+		SetAnnotation(&pod, "c", "d").
+		AppendContainer("/spec", &pod.Spec, corev1.Container{Name: "proxy", Image: "proxy"}).
+		AddToleration("/spec", &pod.Spec, corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpExists}).
+		AddToleration("/spec", &pod.Spec, corev1.Toleration{Key: "spot", Operator: corev1.TolerationOpExists}).
+		EnsureEnvVar("/spec", &pod.Spec, corev1.EnvVar{Name: "MODE", Value: "prod"})
+
+	patched := applyBuilder(t, builder, podRaw)
+	assert.Equal(t, map[string]string{"kubernetes.io/name": "test", "tier": "backend"}, patched.Labels)
+	assert.Equal(t, map[string]string{"a": "b", "c": "d"}, patched.Annotations)
+	require.Len(t, patched.Spec.Containers, 3)
+	assert.Equal(t, "proxy", patched.Spec.Containers[2].Name)
+	assert.Equal(t, []corev1.EnvVar{{Name: "MODE", Value: "prod"}}, patched.Spec.Containers[0].Env)
+	assert.Equal(t, []corev1.EnvVar{{Name: "MODE", Value: "prod"}}, patched.Spec.Containers[1].Env)
+	require.Len(t, patched.Spec.Tolerations, 2)
+	assert.Equal(t, "spot", patched.Spec.Tolerations[1].Key)
+}
+
+func TestPatchBuilderIdempotentHelpers(t *testing.T) {
+	var pod corev1.Pod
+	require.NoError(t, json.Unmarshal(podRaw, &pod))
+	pod.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+	builder := admissionreview.NewPatchBuilder().
+		AddToleration("/spec", &pod.Spec, corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpExists})
+	assert.Equal(t, 0, builder.Len())
+
+	// the toleration seconds are compared by value
+	tolerationSeconds, otherTolerationSeconds := int64(300), int64(300)
+	pod.Spec.Tolerations = []corev1.Toleration{{Key: "node.kubernetes.io/unreachable", Operator: corev1.TolerationOpExists,
+		Effect: corev1.TaintEffectNoExecute, TolerationSeconds: &tolerationSeconds}}
+	builder.AddToleration("/spec", &pod.Spec, corev1.Toleration{Key: "node.kubernetes.io/unreachable", Operator: corev1.TolerationOpExists,
+		Effect: corev1.TaintEffectNoExecute, TolerationSeconds: &otherTolerationSeconds})
+	assert.Equal(t, 0, builder.Len())
+	builder.AddToleration("/spec", &pod.Spec, corev1.Toleration{Key: "node.kubernetes.io/unreachable", Operator: corev1.TolerationOpExists,
+		Effect: corev1.TaintEffectNoExecute})
+	assert.Equal(t, 1, builder.Len())
+
+	builder = admissionreview.NewPatchBuilder()
+	pod.Spec.Containers = pod.Spec.Containers[:1]
+	builder.EnsureEnvVar("/spec", &pod.Spec, corev1.EnvVar{Name: "MODE", Value: "dev"})
+	assert.Equal(t, 0, builder.Len())
+}
+
+func TestBuilderMutatingReviewer(t *testing.T) {
+	arRequestPod := &admissionv1.AdmissionRequest{
+		UID:    "123",
+		Kind:   *podGroupVersionKind,
+		Object: runtime.RawExtension{Raw: podRaw},
+	}
+	reviewer := admissionreview.BuilderMutatingReviewer(func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().SetLabel(request, "tier", "backend")
+	}, admissionreview.WithKinds(podGroupVersionKind))
+	testResult := reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.Equal(t, patchType, *testResult.PatchType)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/labels","value":{"tier":"backend"}}]`, string(testResult.Patch))
+
+	reviewer = admissionreview.BuilderMutatingReviewer(func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, nil
+	}, admissionreview.WithKinds(podGroupVersionKind))
+	testResult = reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.Nil(t, testResult.Patch)
+}
+
+// applyBuilder applies the operations of the builder onto the raw pod and returns the unmarshalled result
+func applyBuilder(t *testing.T, builder *admissionreview.PatchBuilder, rawPod []byte) *corev1.Pod {
+	patchJson, err := json.Marshal(builder.Patch())
+	require.NoError(t, err)
+	patch, err := jsonpatch.DecodePatch(patchJson)
+	require.NoError(t, err)
+	patchedRaw, err := patch.Apply(rawPod)
+	require.NoError(t, err)
+	var patched corev1.Pod
+	require.NoError(t, json.Unmarshal(patchedRaw, &patched))
+	return &patched
+}
@@ -0,0 +1,230 @@
+package admissionreview_test
+
+import (
+	"net/http"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wI2L/jsondiff"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type partialDataType struct {
+	Test  string            `json:"test,omitempty"`
+	Count int               `json:"count"`
+	Items []partialDataType `json:"items,omitempty"`
+}
+
+var arRequestUnknownFields = &admissionv1.AdmissionRequest{
+	UID:  "123",
+	Kind: *groupVersionKind,
+	Object: runtime.RawExtension{
+		Raw: []byte(`{"test":"123","unknown":{"a":1},"items":[{"test":"a","unknown":"b"}]}`),
+	},
+}
+
+func TestMutatingReviewPreservesUnknownFields(t *testing.T) {
+	resourceMutaterMock := func(request *partialDataType) (*admissionreview.ValidateResult, *admissionreview.Patch[partialDataType]) {
+		response := *request
+		response.Test = "234"
+		response.Items = []partialDataType{{Test: "c"}}
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[partialDataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	reviewer := admissionreview.MutatingReviewer(resourceMutaterMock, groupVersionKind)
+	testResult := reviewer.Review(arRequestUnknownFields)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"replace","path":"/items/0/test","value":"c"},{"op":"replace","path":"/test","value":"234"}]`, string(testResult.Patch))
+}
+
+func TestMutatingReviewArrayInsert(t *testing.T) {
+	prependingMutater := func(meta *admissionreview.RequestMeta, request *partialDataType) (*admissionreview.ValidateResult, *admissionreview.Patch[partialDataType]) {
+		response := *request
+		response.Items = append([]partialDataType{{Test: "new"}}, request.Items...)
+		return &admissionreview.ValidateResult{Allow: true}, &admissionreview.Patch[partialDataType]{Request: request, Response: &response}
+	}
+	expected := `{"test":"123","unknown":{"a":1},"items":[{"test":"new","count":0},{"test":"a","unknown":"b"}]}`
+	for _, diffOptions := range [][]jsondiff.Option{nil, {jsondiff.Factorize(), jsondiff.Rationalize()}} {
+		reviewer := admissionreview.RequestMutatingReviewer(prependingMutater, admissionreview.WithKinds(groupVersionKind), admissionreview.WithDiffOptions(diffOptions...))
+		testResult := reviewer.Review(arRequestUnknownFields)
+		assert.True(t, testResult.Allowed)
+		assert.JSONEq(t, expected, string(applyPatch(t, arRequestUnknownFields.Object.Raw, testResult.Patch)))
+	}
+}
+
+func TestMutatingReviewArrayReorder(t *testing.T) {
+	arRequestItems := &admissionv1.AdmissionRequest{
+		UID:  "123",
+		Kind: *groupVersionKind,
+		Object: runtime.RawExtension{
+			Raw: []byte(`{"items":[{"test":"a","unknown":"a"},{"test":"b","unknown":"b"}]}`),
+		},
+	}
+	reversingMutater := func(meta *admissionreview.RequestMeta, request *partialDataType) (*admissionreview.ValidateResult, *admissionreview.Patch[partialDataType]) {
+		response := *request
+		response.Items = []partialDataType{request.Items[1], request.Items[0]}
+		response.Items[0].Count = 1
+		return &admissionreview.ValidateResult{Allow: true}, &admissionreview.Patch[partialDataType]{Request: request, Response: &response}
+	}
+	reviewer := admissionreview.RequestMutatingReviewer(reversingMutater, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequestItems)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `{"items":[{"test":"b","unknown":"b","count":1},{"test":"a","unknown":"a"}]}`,
+		string(applyPatch(t, arRequestItems.Object.Raw, testResult.Patch)))
+}
+
+// applyPatch applies the JSON Patch onto the raw object.
+func applyPatch(t *testing.T, rawObject []byte, patch []byte) []byte {
+	decodedPatch, err := jsonpatch.DecodePatch(patch)
+	require.NoError(t, err)
+	result, err := decodedPatch.Apply(rawObject)
+	require.NoError(t, err)
+	return result
+}
+
+func TestMutatingReviewNormalisedFields(t *testing.T) {
+	resourceMutaterMock := func(request *partialDataType) (*admissionreview.ValidateResult, *admissionreview.Patch[partialDataType]) {
+		response := *request
+		response.Count = 5
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[partialDataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	reviewer := admissionreview.MutatingReviewer(resourceMutaterMock, groupVersionKind)
+	testResult := reviewer.Review(arRequestUnknownFields)
+	assert.True(t, testResult.Allowed)
+	// count is absent in the raw object, hence it has to be added instead of replaced
+	assert.JSONEq(t, `[{"op":"add","path":"/count","value":5}]`, string(testResult.Patch))
+}
+
+func TestMutatingReviewTestOperations(t *testing.T) {
+	resourceMutaterMock := func(meta *admissionreview.RequestMeta, request *partialDataType) (*admissionreview.ValidateResult, *admissionreview.Patch[partialDataType]) {
+		response := *request
+		response.Test = "234"
+		response.Count = 5
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[partialDataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	reviewer := admissionreview.RequestMutatingReviewer(resourceMutaterMock, admissionreview.WithKinds(groupVersionKind), admissionreview.WithTestOperations())
+	testResult := reviewer.Review(arRequestUnknownFields)
+	assert.True(t, testResult.Allowed)
+	// only the replaced path is guarded, count is added
+	assert.JSONEq(t, `[{"op":"test","path":"/test","value":"123"},{"op":"add","path":"/count","value":5},{"op":"replace","path":"/test","value":"234"}]`, string(testResult.Patch))
+}
+
+func TestMutatingReviewIdempotencyCheck(t *testing.T) {
+	arRequestPod := &admissionv1.AdmissionRequest{
+		UID:    "123",
+		Kind:   *podGroupVersionKind,
+		Object: runtime.RawExtension{Raw: podRaw},
+	}
+	idempotentMutater := func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().SetLabel(request, "tier", "backend")
+	}
+	reviewer := admissionreview.BuilderMutatingReviewer(idempotentMutater, admissionreview.WithKinds(podGroupVersionKind), admissionreview.WithIdempotencyCheck())
+	testResult := reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.NotNil(t, testResult.Patch)
+
+	// appending to the list only if the entry is missing is idempotent
+	tolerationMutater := func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.Patch[corev1.Pod]) {
+		toleration := corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpExists}
+		for _, present := range request.Spec.Tolerations {
+			if present == toleration {
+				return &admissionreview.ValidateResult{Allow: true}, nil
+			}
+		}
+		response := request.DeepCopy()
+		response.Spec.Tolerations = append(response.Spec.Tolerations, toleration)
+		return &admissionreview.ValidateResult{Allow: true}, &admissionreview.Patch[corev1.Pod]{Request: request, Response: response}
+	}
+	tolerationReviewer := admissionreview.RequestMutatingReviewer(tolerationMutater, admissionreview.WithKinds(podGroupVersionKind), admissionreview.WithIdempotencyCheck())
+	podWithTolerations := []byte(`{"metadata":{"name":"test"},"spec":{"containers":[{"name":"app","image":"app"}],"tolerations":[{"key":"spot","operator":"Exists"}]}}`)
+	arRequestPod.Object.Raw = podWithTolerations
+	testResult = tolerationReviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"add","path":"/spec/tolerations/-","value":{"key":"dedicated","operator":"Exists"}}]`, string(testResult.Patch))
+	arRequestPod.Object.Raw = applyPatch(t, podWithTolerations, testResult.Patch)
+	testResult = tolerationReviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.Nil(t, testResult.Patch)
+	arRequestPod.Object.Raw = podRaw
+
+	// appending to the list unconditionally is not idempotent
+	appendingMutater := func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().Add("/spec/containers/-", corev1.Container{Name: "proxy"})
+	}
+	reviewer = admissionreview.BuilderMutatingReviewer(appendingMutater, admissionreview.WithKinds(podGroupVersionKind), admissionreview.WithIdempotencyCheck())
+	testResult = reviewer.Review(arRequestPod)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code)
+	assert.Nil(t, testResult.Patch)
+}
+
+func TestMutatingReviewDiffOptions(t *testing.T) {
+	resourceMutaterMock := func(meta *admissionreview.RequestMeta, request *partialDataType) (*admissionreview.ValidateResult, *admissionreview.Patch[partialDataType]) {
+		response := *request
+		response.Test = "234"
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[partialDataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	reviewer := admissionreview.RequestMutatingReviewer(resourceMutaterMock, admissionreview.WithKinds(groupVersionKind), admissionreview.WithDiffOptions(jsondiff.Invertible()))
+	testResult := reviewer.Review(arRequestUnknownFields)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"test","path":"/test","value":"123"},{"op":"replace","path":"/test","value":"234"}]`, string(testResult.Patch))
+}
+
+func TestMutatingReviewForbiddenOperations(t *testing.T) {
+	arRequestPod := &admissionv1.AdmissionRequest{
+		UID:    "123",
+		Kind:   *podGroupVersionKind,
+		Object: runtime.RawExtension{Raw: podRaw},
+	}
+	forbidden := admissionreview.WithForbiddenOperations(
+		admissionreview.ForbiddenOperation{Path: "/metadata/ownerReferences", Types: []string{jsondiff.OperationRemove}},
+		admissionreview.ForbiddenOperation{Path: "/status"},
+	)
+	for _, tc := range []struct {
+		name    string
+		builder *admissionreview.PatchBuilder
+		allowed bool
+	}{
+		{name: "label", builder: admissionreview.NewPatchBuilder().Add("/metadata/labels", map[string]string{"tier": "backend"}), allowed: true},
+		{name: "add ownerReference", builder: admissionreview.NewPatchBuilder().Add("/metadata/ownerReferences", []any{}), allowed: true},
+		{name: "remove ownerReference", builder: admissionreview.NewPatchBuilder().Remove("/metadata/ownerReferences/0"), allowed: false},
+		{name: "status", builder: admissionreview.NewPatchBuilder().Add("/status/phase", "Running"), allowed: false},
+		{name: "test status", builder: admissionreview.NewPatchBuilder().Test("/status", nil), allowed: true},
+		{name: "status prefix", builder: admissionreview.NewPatchBuilder().Add("/statusText", "a"), allowed: true},
+	} {
+		builder := tc.builder
+		reviewer := admissionreview.BuilderMutatingReviewer(func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+			return &admissionreview.ValidateResult{Allow: true}, builder
+		}, admissionreview.WithKinds(podGroupVersionKind), forbidden)
+		testResult := reviewer.Review(arRequestPod)
+		assert.Equal(t, tc.allowed, testResult.Allowed, tc.name)
+		if !tc.allowed {
+			assert.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code, tc.name)
+			assert.Nil(t, testResult.Patch, tc.name)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package admissionreview
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wI2L/jsondiff"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PathScopeViolationPolicy determines how JSON Patch operations outside of the path scope of a mutating reviewer are handled,
+// see WithAllowedPaths and WithDeniedPaths. The offending operations are logged for all policies.
+type PathScopeViolationPolicy int
+
+const (
+	// PathScopeViolationDeny denies the admission request with an InternalServerError status.
+	PathScopeViolationDeny PathScopeViolationPolicy = iota
+	// PathScopeViolationStrip removes the offending operations from the JSON Patch and applies the remaining ones.
+	PathScopeViolationStrip
+)
+
+// pathScope holds the JSON pointer patterns that restrict the paths mutaters may modify.
+type pathScope struct {
+	// allowed are the patterns of the paths that may be modified, empty means all paths.
+	allowed []string
+	// denied are the patterns of the paths that must not be modified, takes precedence over allowed.
+	denied []string
+	// violationPolicy determines how operations outside of the scope are handled.
+	violationPolicy PathScopeViolationPolicy
+}
+
+// isRestricted checks whether any paths are restricted.
+func (scope *pathScope) isRestricted() bool {
+	return len(scope.allowed) != 0 || len(scope.denied) != 0
+}
+
+// inScope checks whether the operation only modifies paths within the scope. Test operations do not modify the object and are always in scope.
+// The source path of move operations is modified as well.
+func (scope *pathScope) inScope(op *jsondiff.Operation) bool {
+	switch op.Type {
+	case jsondiff.OperationTest:
+		return true
+	case jsondiff.OperationMove:
+		return scope.pathInScope(op.From) && scope.pathInScope(op.Path)
+	default:
+		return scope.pathInScope(op.Path)
+	}
+}
+
+// pathInScope checks whether the JSON pointer path is matched by an allowed pattern and overlaps no denied pattern.
+func (scope *pathScope) pathInScope(path string) bool {
+	for _, pattern := range scope.denied {
+		if overlapsPath(pattern, path) {
+			return false
+		}
+	}
+	if len(scope.allowed) == 0 {
+		return true
+	}
+	for _, pattern := range scope.allowed {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath checks whether the JSON pointer path equals the pattern or is nested below it.
+// Each token of the pattern may be the Wildcard, which matches any single token, e.g. "/metadata/labels/*". See pointerTokens for the normalisation.
+func matchPath(pattern string, path string) bool {
+	patternTokens, pathTokens := pointerTokens(pattern), pointerTokens(path)
+	return len(pathTokens) >= len(patternTokens) && matchTokens(patternTokens, pathTokens)
+}
+
+// overlapsPath checks whether an operation on the JSON pointer path modifies paths matched by the pattern,
+// i.e. whether the path is matched by the pattern (see matchPath) or is a parent of a path matched by the pattern.
+func overlapsPath(pattern string, path string) bool {
+	return matchTokens(pointerTokens(pattern), pointerTokens(path))
+}
+
+// matchTokens checks whether the tokens match the pattern tokens up to the length of the shorter one.
+func matchTokens(patternTokens []string, pathTokens []string) bool {
+	for i := 0; i < len(patternTokens) && i < len(pathTokens); i++ {
+		if !matchField(patternTokens[i], pathTokens[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pointerTokens splits the JSON pointer into its unescaped tokens according to RFC 6901. Patterns are normalised,
+// i.e. trailing slashes are ignored and a missing leading slash is implied, e.g. "/metadata/" and "metadata" equal "/metadata".
+// The empty pointer and "/" refer to the whole document.
+func pointerTokens(pointer string) []string {
+	pointer = strings.Trim(pointer, "/")
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(pointer, "/")
+	for i, token := range tokens {
+		tokens[i] = unescapePointerToken(token)
+	}
+	return tokens
+}
+
+// unescapePointerToken reverts EscapePointerToken.
+func unescapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// applyPathScope checks the JSON Patch against the path scope and logs the offending operations.
+// Depending on the violation policy the offending operations are stripped or a validateResult that denies the request is returned.
+func applyPathScope(ctx context.Context, uid types.UID, scope *pathScope, patch jsondiff.Patch) (jsondiff.Patch, *ValidateResult) {
+	if !scope.isRestricted() {
+		return patch, nil
+	}
+	var inScope, outOfScope jsondiff.Patch
+	for i := range patch {
+		if scope.inScope(&patch[i]) {
+			inScope = append(inScope, patch[i])
+		} else {
+			outOfScope = append(outOfScope, patch[i])
+		}
+	}
+	if len(outOfScope) == 0 {
+		return patch, nil
+	}
+	if scope.violationPolicy == PathScopeViolationStrip {
+		loggerFromContext(ctx).Log(LogLevelWarn, "Stripped operations outside of the path scope from the JSON patch", "uid", uid, "operations", outOfScope.String())
+		return inScope, nil
+	}
+	loggerFromContext(ctx).Log(LogLevelWarn, "Denied admission request due to operations outside of the path scope", "uid", uid, "operations", outOfScope.String())
+	return nil, &ValidateResult{
+		Allow:  false,
+		Status: GetErrorStatus(http.StatusInternalServerError, "mutation violates the path scope", fmt.Errorf("operations outside of the path scope: %s", outOfScope.String())),
+	}
+}
@@ -0,0 +1,81 @@
+package admissionreview_test
+
+import (
+	"net/http"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var arRequestPod = &admissionv1.AdmissionRequest{
+	UID:    "123",
+	Kind:   *podGroupVersionKind,
+	Object: runtime.RawExtension{Raw: podRaw},
+}
+
+// scopedMutater sets an annotation, the image of the first container and the phase of the status
+func scopedMutater(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+	return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().
+		SetAnnotation(request, "c", "d").
+		Replace("/spec/containers/0/image", "app:v2").
+		Add("/status", map[string]string{"phase": "Running"})
+}
+
+func TestPathScopeDeny(t *testing.T) {
+	reviewer := admissionreview.BuilderMutatingReviewer(scopedMutater, admissionreview.WithKinds(podGroupVersionKind),
+		admissionreview.WithAllowedPaths("/metadata/annotations/*", "/spec/containers/*/image"))
+	testResult := reviewer.Review(arRequestPod)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code)
+	assert.Nil(t, testResult.Patch)
+
+	reviewer = admissionreview.BuilderMutatingReviewer(scopedMutater, admissionreview.WithKinds(podGroupVersionKind),
+		admissionreview.WithAllowedPaths("/metadata/annotations/*", "/spec/containers/*/image", "/status"))
+	testResult = reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/annotations/c","value":"d"},{"op":"replace","path":"/spec/containers/0/image","value":"app:v2"},{"op":"add","path":"/status","value":{"phase":"Running"}}]`, string(testResult.Patch))
+}
+
+func TestPathScopeStrip(t *testing.T) {
+	reviewer := admissionreview.BuilderMutatingReviewer(scopedMutater, admissionreview.WithKinds(podGroupVersionKind),
+		admissionreview.WithDeniedPaths("/status", "/spec/containers/*/image"), admissionreview.WithPathScopeViolationPolicy(admissionreview.PathScopeViolationStrip))
+	testResult := reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/annotations/c","value":"d"}]`, string(testResult.Patch))
+
+	// denied paths take precedence, no patch remains
+	reviewer = admissionreview.BuilderMutatingReviewer(scopedMutater, admissionreview.WithKinds(podGroupVersionKind),
+		admissionreview.WithAllowedPaths("/metadata", "/spec", "/status"), admissionreview.WithDeniedPaths("/metadata/annotations", "/spec", "/status"),
+		admissionreview.WithPathScopeViolationPolicy(admissionreview.PathScopeViolationStrip))
+	testResult = reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.Nil(t, testResult.Patch)
+	assert.Nil(t, testResult.PatchType)
+}
+
+func TestPathScopeNormalisation(t *testing.T) {
+	labelMutater := func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().SetLabel(request, "app.kubernetes.io/name", "test")
+	}
+	// trailing slashes are ignored, parents of denied paths are denied as well
+	for _, deniedPath := range []string{"/metadata/", "/metadata/labels/app.kubernetes.io~1name", "metadata/labels/*"} {
+		reviewer := admissionreview.BuilderMutatingReviewer(labelMutater, admissionreview.WithKinds(podGroupVersionKind), admissionreview.WithDeniedPaths(deniedPath))
+		testResult := reviewer.Review(arRequestPod)
+		assert.False(t, testResult.Allowed, deniedPath)
+		assert.Nil(t, testResult.Patch)
+	}
+
+	reviewer := admissionreview.BuilderMutatingReviewer(labelMutater, admissionreview.WithKinds(podGroupVersionKind), admissionreview.WithAllowedPaths("/metadata/"))
+	testResult := reviewer.Review(arRequestPod)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/labels","value":{"app.kubernetes.io/name":"test"}}]`, string(testResult.Patch))
+
+	reviewer = admissionreview.BuilderMutatingReviewer(labelMutater, admissionreview.WithKinds(podGroupVersionKind),
+		admissionreview.WithForbiddenOperations(admissionreview.ForbiddenOperation{Path: "/metadata/labels/"}))
+	testResult = reviewer.Review(arRequestPod)
+	assert.False(t, testResult.Allowed)
+}
@@ -0,0 +1,72 @@
+package admissionreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errNoResponse is reported if a reviewer or review function returns nil instead of a result.
+var errNoResponse = errors.New("reviewer returned nil")
+
+// failurePolicyContextKey is the context key for the failure policy that applies to panics and missing results.
+type failurePolicyContextKey struct{}
+
+// withFailurePolicy returns a copy of the context that carries the failure policy.
+func withFailurePolicy(ctx context.Context, failurePolicy admissionregistrationv1.FailurePolicyType) context.Context {
+	return context.WithValue(ctx, failurePolicyContextKey{}, failurePolicy)
+}
+
+// failurePolicyFromContext returns the failure policy carried by the context. Defaults to Fail.
+func failurePolicyFromContext(ctx context.Context) admissionregistrationv1.FailurePolicyType {
+	if failurePolicy, ok := ctx.Value(failurePolicyContextKey{}).(admissionregistrationv1.FailurePolicyType); ok {
+		return failurePolicy
+	}
+	return admissionregistrationv1.Fail
+}
+
+// safeReview calls the review function and converts panics as well as nil responses into a failure response according to the failure policy
+// of the context, see WithFailurePolicy. The stack trace of panics is logged. The UID of the response is set to the one of the request if missing.
+func safeReview(ctx context.Context, arRequest *admissionv1.AdmissionRequest, reviewFunc func() *admissionv1.AdmissionResponse) (response *admissionv1.AdmissionResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			loggerFromContext(ctx).Log(LogLevelError, "Admission review panicked", "uid", requestUID(arRequest), "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			response = failureResponse(requestUID(arRequest), failurePolicyFromContext(ctx),
+				GetErrorStatus(http.StatusInternalServerError, "admission review panicked", fmt.Errorf("%v", r)))
+		}
+	}()
+	response = reviewFunc()
+	if response == nil {
+		loggerFromContext(ctx).Log(LogLevelError, "Admission review did not return a result", "uid", requestUID(arRequest))
+		return failureResponse(requestUID(arRequest), failurePolicyFromContext(ctx),
+			GetErrorStatus(http.StatusInternalServerError, "admission review did not return a result", errNoResponse))
+	}
+	if response.UID == "" {
+		response.UID = requestUID(arRequest)
+	}
+	return response
+}
+
+// failureResponse constructs the admissionResponse for failed reviews according to the failurePolicy.
+// The request is allowed with a warning for the failure policy Ignore and denied with the given status otherwise.
+func failureResponse(uid types.UID, failurePolicy admissionregistrationv1.FailurePolicyType, status *metav1.Status) *admissionv1.AdmissionResponse {
+	if failurePolicy == admissionregistrationv1.Ignore {
+		return &admissionv1.AdmissionResponse{
+			UID:      uid,
+			Allowed:  true,
+			Warnings: []string{status.Message + ", request allowed due to failure policy Ignore"},
+		}
+	}
+	return &admissionv1.AdmissionResponse{
+		UID:     uid,
+		Allowed: false,
+		Result:  status,
+	}
+}
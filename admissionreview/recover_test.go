@@ -0,0 +1,79 @@
+package admissionreview_test
+
+import (
+	"net/http"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+// panickingReviewer is a Reviewer implementation that is not wrapped via ReviewFunc and panics.
+type panickingReviewer struct{}
+
+func (reviewer *panickingReviewer) Review(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	panic("test")
+}
+
+func TestReviewRecoversPanic(t *testing.T) {
+	reviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		panic("test")
+	}, groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	require.Equal(t, arRequest.UID, testResult.UID)
+	require.False(t, testResult.Allowed)
+	require.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code)
+}
+
+func TestReviewNilResult(t *testing.T) {
+	reviewer := admissionreview.MutatingReviewer(func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		return nil, nil
+	}, groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	require.Equal(t, arRequest.UID, testResult.UID)
+	require.False(t, testResult.Allowed)
+	require.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code)
+
+	reviewer = admissionreview.ReviewFunc(func(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	})
+	testResult = reviewer.Review(arRequest)
+	require.Equal(t, arRequest.UID, testResult.UID)
+	require.True(t, testResult.Allowed)
+}
+
+func TestHandlerRecoversPanic(t *testing.T) {
+	handler := admissionreview.NewHandler(&panickingReviewer{})
+	resp := serveAdmissionReview(t, handler, arRequest)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.False(t, resp.Response.Allowed)
+	require.Equal(t, http.StatusInternalServerError, int(resp.Response.Result.Code))
+
+	handler = admissionreview.NewHandler(&panickingReviewer{}, admissionreview.WithFailurePolicy(admissionregistrationv1.Ignore))
+	resp = serveAdmissionReview(t, handler, arRequest)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.True(t, resp.Response.Allowed)
+	require.NotEmpty(t, resp.Response.Warnings)
+}
+
+func TestHandlerNilResultIgnore(t *testing.T) {
+	reviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return nil
+	}, groupVersionKind)
+	handler := admissionreview.NewHandler(reviewer, admissionreview.WithFailurePolicy(admissionregistrationv1.Ignore))
+	resp := serveAdmissionReview(t, handler, arRequest)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.True(t, resp.Response.Allowed)
+	require.NotEmpty(t, resp.Response.Warnings)
+}
+
+func TestReviewNilRequest(t *testing.T) {
+	reviewer := admissionreview.MutatingReviewer(func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		return &admissionreview.ValidateResult{Allow: true}, nil
+	}, groupVersionKind)
+	testResult := reviewer.Review(nil)
+	require.False(t, testResult.Allowed)
+	require.Equal(t, int32(http.StatusInternalServerError), testResult.Result.Code)
+}
@@ -0,0 +1,115 @@
+package admissionreview
+
+import (
+	"context"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RequestMeta holds the metadata of the admission request, i.e. everything apart from the object and old object.
+type RequestMeta struct {
+	// UID identifies the individual admission request.
+	UID types.UID
+	// Kind is the fully-qualified type of the object being submitted.
+	Kind metav1.GroupVersionKind
+	// Resource is the fully-qualified resource being requested.
+	Resource metav1.GroupVersionResource
+	// SubResource is the subresource being requested, if any (e.g. "status" or "scale").
+	SubResource string
+	// RequestKind is the fully-qualified type of the original API request, might differ from Kind if the request has been converted.
+	RequestKind *metav1.GroupVersionKind
+	// RequestResource is the fully-qualified resource of the original API request, might differ from Resource if the request has been converted.
+	RequestResource *metav1.GroupVersionResource
+	// RequestSubResource is the subresource of the original API request.
+	RequestSubResource string
+	// Name is the name of the object as presented in the request.
+	Name string
+	// Namespace is the namespace associated with the request (if any).
+	Namespace string
+	// Operation is the operation being performed, e.g. CREATE or DELETE.
+	Operation admissionv1.Operation
+	// UserInfo is information about the requesting user.
+	UserInfo authenticationv1.UserInfo
+	// DryRun indicates that modifications will definitely not be persisted for this request.
+	DryRun bool
+	// Options is the raw operation option structure, e.g. meta.k8s.io/v1.CreateOptions for CREATE operations.
+	Options runtime.RawExtension
+}
+
+// NewRequestMeta extracts the RequestMeta from the given admission request.
+func NewRequestMeta(arRequest *admissionv1.AdmissionRequest) *RequestMeta {
+	return &RequestMeta{
+		UID:                arRequest.UID,
+		Kind:               arRequest.Kind,
+		Resource:           arRequest.Resource,
+		SubResource:        arRequest.SubResource,
+		RequestKind:        arRequest.RequestKind,
+		RequestResource:    arRequest.RequestResource,
+		RequestSubResource: arRequest.RequestSubResource,
+		Name:               arRequest.Name,
+		Namespace:          arRequest.Namespace,
+		Operation:          arRequest.Operation,
+		UserInfo:           arRequest.UserInfo,
+		DryRun:             arRequest.DryRun != nil && *arRequest.DryRun,
+		Options:            arRequest.Options,
+	}
+}
+
+// ResourceRequestValidator receives the metadata of the admission request alongside the unmarshalled request object.
+// The request object is nil for DELETE operations.
+// Errors should be handled internally and modify the resulting ValidateResult accordingly.
+type ResourceRequestValidator[T any] func(meta *RequestMeta, request *T) *ValidateResult
+
+// ResourceRequestMutater receives the metadata of the admission request alongside the unmarshalled request object.
+// The request object is nil for DELETE operations. The returned Patch has the same semantics as for the ResourceMutater.
+type ResourceRequestMutater[T any] func(meta *RequestMeta, request *T) (*ValidateResult, *Patch[T])
+
+// RequestValidatingReviewer is the implementation of the ReviewerHandler interface for validations that depend on the context
+// of the admission request, e.g. the operation or the requesting user. Requests that are not selected
+// via the WithKinds or WithMatcher options are handled without calling the validator, see WithKindMismatchPolicy.
+func RequestValidatingReviewer[T any](validator ResourceRequestValidator[T], opts ...ReviewerOption) ReviewerHandler {
+	options := newReviewerOptions(opts)
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if skipValidate := options.skip(ctx, arRequest); skipValidate != nil {
+			return skipValidate.admissionResponse(arRequest.UID)
+		}
+		_, span := startSpan(ctx, spanDecodeObject)
+		request, warnings, skipValidate := unmarshallOptionalObject[T](arRequest.Object.Raw, options.strictDecoding)
+		span.End()
+		if skipValidate != nil {
+			return skipValidate.admissionResponse(arRequest.UID)
+		}
+		_, span = startSpan(ctx, spanReviewFunction)
+		defer span.End()
+		return withWarnings(validator(NewRequestMeta(arRequest), request).admissionResponse(arRequest.UID), warnings)
+	})
+}
+
+// RequestMutatingReviewer is the implementation of the ReviewerHandler interface for mutations that depend on the context
+// of the admission request. Requests that are not selected via the WithKinds or WithMatcher options
+// are handled without calling the mutater, see WithKindMismatchPolicy. Otherwise, a JSON Patch is constructed from the result of the mutater and wrapped into an admissionResponse.
+func RequestMutatingReviewer[T any](mutater ResourceRequestMutater[T], opts ...ReviewerOption) ReviewerHandler {
+	options := newReviewerOptions(opts)
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if skipMutate := options.skip(ctx, arRequest); skipMutate != nil {
+			return skipMutate.admissionResponse(arRequest.UID)
+		}
+		_, span := startSpan(ctx, spanDecodeObject)
+		request, warnings, skipMutate := unmarshallOptionalObject[T](arRequest.Object.Raw, options.strictDecoding)
+		span.End()
+		if skipMutate != nil {
+			return skipMutate.admissionResponse(arRequest.UID)
+		}
+		_, span = startSpan(ctx, spanReviewFunction)
+		meta := NewRequestMeta(arRequest)
+		result, patches := mutater(meta, request)
+		span.End()
+		remutate := patchRemutater(func(request *T) (*ValidateResult, *Patch[T]) {
+			return mutater(meta, request)
+		})
+		return withWarnings(patchResponse(ctx, arRequest.UID, arRequest.Object.Raw, options, result, patches, remutate), warnings)
+	})
+}
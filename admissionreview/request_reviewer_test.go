@@ -0,0 +1,82 @@
+package admissionreview_test
+
+import (
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRequestValidatingReview(t *testing.T) {
+	dryRun := true
+	arRequestWithMeta := &admissionv1.AdmissionRequest{
+		UID:       arRequest.UID,
+		Kind:      *groupVersionKind,
+		Name:      "test",
+		Operation: admissionv1.Create,
+		UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+		DryRun:    &dryRun,
+		Object:    runtime.RawExtension{Raw: data},
+	}
+	var receivedMeta *admissionreview.RequestMeta
+	var received *dataType
+	resourceValidatorMock := func(meta *admissionreview.RequestMeta, request *dataType) *admissionreview.ValidateResult {
+		receivedMeta, received = meta, request
+		return &admissionreview.ValidateResult{
+			Allow:  false,
+			Status: status,
+		}
+	}
+	reviewer := admissionreview.RequestValidatingReviewer(resourceValidatorMock, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequestWithMeta)
+	assert.Equal(t, arResponseFailure, testResult)
+	assert.Equal(t, &dataType{Test: "123"}, received)
+	assert.Equal(t, arRequest.UID, receivedMeta.UID)
+	assert.Equal(t, "test", receivedMeta.Name)
+	assert.Equal(t, admissionv1.Create, receivedMeta.Operation)
+	assert.Equal(t, "admin", receivedMeta.UserInfo.Username)
+	assert.True(t, receivedMeta.DryRun)
+}
+
+func TestRequestMutatingReviewAllowed(t *testing.T) {
+	resourceMutaterMock := func(meta *admissionreview.RequestMeta, request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		response := *request
+		response.Test2 = "234"
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[dataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	reviewer := admissionreview.RequestMutatingReviewer(resourceMutaterMock, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequest)
+	assert.Equal(t, arResponseMutatingSuccess, testResult)
+}
+
+func TestRequestValidatingReviewKindMismatch(t *testing.T) {
+	resourceValidatorMock := func(meta *admissionreview.RequestMeta, request *dataType) *admissionreview.ValidateResult {
+		assert.Fail(t, "validator should not be called for incompatible kinds")
+		return nil
+	}
+	otherGroupVersionKind := *groupVersionKind
+	otherGroupVersionKind.Kind = "Pod"
+	reviewer := admissionreview.RequestValidatingReviewer(resourceValidatorMock, admissionreview.WithKinds(&otherGroupVersionKind))
+	testResult := reviewer.Review(arRequest)
+	assert.True(t, testResult.Allowed)
+}
+
+func TestRequestValidatingReviewWithoutMatchers(t *testing.T) {
+	called := false
+	resourceValidatorMock := func(meta *admissionreview.RequestMeta, request *dataType) *admissionreview.ValidateResult {
+		called = true
+		return &admissionreview.ValidateResult{Allow: false, Status: status}
+	}
+	reviewer := admissionreview.RequestValidatingReviewer(resourceValidatorMock)
+	testResult := reviewer.Review(arRequest)
+	assert.True(t, called)
+	assert.False(t, testResult.Allowed)
+}
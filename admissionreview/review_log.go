@@ -0,0 +1,58 @@
+package admissionreview
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// logReview emits the structured log event for the review of the admission request according to the level and sampling of the options,
+// see WithReviewLogLevel and WithReviewLogSampling.
+func logReview(options *handlerOptions, arRequest *admissionv1.AdmissionRequest, response *admissionv1.AdmissionResponse, latency time.Duration) {
+	if options.reviewLogSampling == 0 || arRequest == nil || response == nil {
+		return
+	}
+	if (options.reviewLogCounter.Add(1)-1)%options.reviewLogSampling != 0 {
+		return
+	}
+	options.logger.Log(options.reviewLogLevel, "Admission review",
+		"uid", arRequest.UID,
+		"group", arRequest.Kind.Group,
+		"version", arRequest.Kind.Version,
+		"kind", arRequest.Kind.Kind,
+		"namespace", arRequest.Namespace,
+		"name", arRequest.Name,
+		"operation", arRequest.Operation,
+		"user", arRequest.UserInfo.Username,
+		"allowed", response.Allowed,
+		"code", responseCode(response),
+		"patchOperations", patchOperationCount(response.Patch),
+		"latency", latency,
+	)
+}
+
+// responseCode returns the status code of the response. Defaults to the codes used by the API server if the response has no status code,
+// i.e. OK for allowed and Forbidden for denied requests.
+func responseCode(response *admissionv1.AdmissionResponse) int32 {
+	if response.Result != nil && response.Result.Code != 0 {
+		return response.Result.Code
+	}
+	if response.Allowed {
+		return http.StatusOK
+	}
+	return http.StatusForbidden
+}
+
+// patchOperationCount returns the number of operations of the JSON Patch, zero if the patch is absent or malformed.
+func patchOperationCount(patch []byte) int {
+	if len(patch) == 0 {
+		return 0
+	}
+	var operations []json.RawMessage
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return 0
+	}
+	return len(operations)
+}
@@ -0,0 +1,68 @@
+package admissionreview_test
+
+import (
+	"testing"
+	"time"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandlerReviewLog(t *testing.T) {
+	arRequestPod := &admissionv1.AdmissionRequest{
+		UID:       "123",
+		Kind:      *podGroupVersionKind,
+		Namespace: "default",
+		Name:      "test",
+		Operation: admissionv1.Create,
+		UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+		Object:    runtime.RawExtension{Raw: podRaw},
+	}
+	reviewer := admissionreview.BuilderMutatingReviewer(func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.PatchBuilder) {
+		return &admissionreview.ValidateResult{Allow: true}, admissionreview.NewPatchBuilder().SetLabel(request, "tier", "backend").SetAnnotation(request, "c", "d")
+	}, admissionreview.WithKinds(podGroupVersionKind))
+
+	var entries []logEntry
+	handler := admissionreview.NewHandler(reviewer, admissionreview.WithLogger(recordingLogger(&entries)), admissionreview.WithReviewLogLevel(admissionreview.LogLevelDebug))
+	resp := serveAdmissionReview(t, handler, arRequestPod)
+	require.True(t, resp.Response.Allowed)
+	require.Len(t, entries, 1)
+	assert.Equal(t, admissionreview.LogLevelDebug, entries[0].level)
+	fields := make(map[string]any)
+	for i := 0; i < len(entries[0].keysAndValues); i += 2 {
+		fields[entries[0].keysAndValues[i].(string)] = entries[0].keysAndValues[i+1]
+	}
+	assert.IsType(t, time.Duration(0), fields["latency"])
+	delete(fields, "latency")
+	assert.Equal(t, map[string]any{
+		"uid":             arRequestPod.UID,
+		"group":           "",
+		"version":         "v1",
+		"kind":            "Pod",
+		"namespace":       "default",
+		"name":            "test",
+		"operation":       admissionv1.Create,
+		"user":            "admin",
+		"allowed":         true,
+		"code":            int32(200),
+		"patchOperations": 2,
+	}, fields)
+}
+
+func TestHandlerReviewLogSampling(t *testing.T) {
+	reviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true}
+	}, groupVersionKind)
+
+	var entries []logEntry
+	handler := admissionreview.NewHandler(reviewer, admissionreview.WithLogger(recordingLogger(&entries)), admissionreview.WithReviewLogSampling(3))
+	for i := 0; i < 7; i++ {
+		serveAdmissionReview(t, handler, arRequest)
+	}
+	assert.Len(t, entries, 3)
+}
@@ -1,6 +1,7 @@
 package admissionreview
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -14,6 +15,13 @@ type Reviewer interface {
 	Review(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
 }
 
+// ContextReviewer is the context-aware extension of the Reviewer interface. Handle prefers ReviewContext over Review
+// and passes the context of the HTTP request including the configured deadline.
+type ContextReviewer interface {
+	Reviewer
+	ReviewContext(context.Context, *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+}
+
 // ReviewerHandler combines the Reviewer and http.Handler interfaces. Used for functions which provides
 // a reviewer combined with an already setup handler for easy use in combination with the http package.
 type ReviewerHandler interface {
@@ -23,24 +31,58 @@ type ReviewerHandler interface {
 
 // Reviewer receives a Kubernetes AdmissionRequest and returns the corresponding admissionResponse
 // Errors should be handled internally and modify the resulting admissionResponse accordingly.
-// Implements the reviewer and http.Handler interface.
+// Implements the ContextReviewer and http.Handler interface, the context only determines the failure policy for panics and nil results.
 type reviewFuncWrapper struct {
 	reviewFunc func(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
 }
 
 func (reviewer *reviewFuncWrapper) Review(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
-	return reviewer.reviewFunc(arRequest)
+	return reviewer.ReviewContext(context.Background(), arRequest)
+}
+
+func (reviewer *reviewFuncWrapper) ReviewContext(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return safeReview(ctx, arRequest, func() *admissionv1.AdmissionResponse {
+		return reviewer.reviewFunc(arRequest)
+	})
 }
 
 func (reviewer *reviewFuncWrapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	Handle(reviewer, w, r)
 }
 
-// ReviewFunc is a helper function to wrap a review function into a corresponding object
+// ReviewFunc is a helper function to wrap a review function into a corresponding object.
+// Panics and nil responses of the review function are converted into a failure response, see WithFailurePolicy.
 func ReviewFunc(reviewFunc func(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) ReviewerHandler {
 	return &reviewFuncWrapper{reviewFunc: reviewFunc}
 }
 
+// contextReviewFuncWrapper is the context-aware counterpart of the reviewFuncWrapper.
+// Implements the ContextReviewer and http.Handler interface.
+type contextReviewFuncWrapper struct {
+	reviewFunc func(context.Context, *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+}
+
+// Review calls the review function with a background context.
+func (reviewer *contextReviewFuncWrapper) Review(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return reviewer.ReviewContext(context.Background(), arRequest)
+}
+
+func (reviewer *contextReviewFuncWrapper) ReviewContext(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return safeReview(ctx, arRequest, func() *admissionv1.AdmissionResponse {
+		return reviewer.reviewFunc(ctx, arRequest)
+	})
+}
+
+func (reviewer *contextReviewFuncWrapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Handle(reviewer, w, r)
+}
+
+// ContextReviewFunc is a helper function to wrap a context-aware review function into a corresponding object.
+// Panics and nil responses are handled as for ReviewFunc.
+func ContextReviewFunc(reviewFunc func(context.Context, *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) ReviewerHandler {
+	return &contextReviewFuncWrapper{reviewFunc: reviewFunc}
+}
+
 // GetErrorStatus receives a suggested HTTP (error) status code, an error description as well as
 // an underlying error and constructs a Failure metav1.Status from this information
 func GetErrorStatus(httpStatus int32, errDiscription string, err error) *metav1.Status {
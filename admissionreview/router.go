@@ -0,0 +1,88 @@
+package admissionreview
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Router dispatches admission requests to the reviewer of the first route whose Matcher matches the request.
+// This allows to serve differently typed reviewers, e.g. for Pods and Deployments, behind a single endpoint.
+// Requests that are not matched by any route are passed to the fallback reviewer if set and otherwise handled according to the fallback policy.
+// The routes have to be set up before the Router is used, it must not be modified concurrently to reviews.
+// Implements the ContextReviewer and http.Handler interface.
+type Router struct {
+	routes         []route
+	fallback       Reviewer
+	fallbackPolicy KindMismatchPolicy
+}
+
+// route binds a Reviewer to the admission requests matched by the Matcher.
+type route struct {
+	matcher  Matcher
+	reviewer Reviewer
+}
+
+// NewRouter returns a Router without routes. Unmatched requests are allowed until Fallback or FallbackPolicy are set.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Route adds a route that passes the admission requests matched by the matcher to the reviewer. Returns the router for chaining.
+func (router *Router) Route(matcher Matcher, reviewer Reviewer) *Router {
+	router.routes = append(router.routes, route{matcher: matcher, reviewer: reviewer})
+	return router
+}
+
+// RouteKinds is a shorthand for Route(MatchKinds(groupVersionKinds...), reviewer).
+func (router *Router) RouteKinds(reviewer Reviewer, groupVersionKinds ...*metav1.GroupVersionKind) *Router {
+	return router.Route(MatchKinds(groupVersionKinds...), reviewer)
+}
+
+// RouteResources is a shorthand for Route(MatchResources(groupVersionResources...), reviewer).
+func (router *Router) RouteResources(reviewer Reviewer, groupVersionResources ...*metav1.GroupVersionResource) *Router {
+	return router.Route(MatchResources(groupVersionResources...), reviewer)
+}
+
+// Fallback sets the reviewer for admission requests that are not matched by any route. Takes precedence over the FallbackPolicy.
+func (router *Router) Fallback(reviewer Reviewer) *Router {
+	router.fallback = reviewer
+	return router
+}
+
+// FallbackPolicy sets the response for admission requests that are not matched by any route if no Fallback reviewer is set.
+// Defaults to KindMismatchAllow.
+func (router *Router) FallbackPolicy(policy KindMismatchPolicy) *Router {
+	router.fallbackPolicy = policy
+	return router
+}
+
+// Review dispatches the admission request with a background context, see ReviewContext.
+func (router *Router) Review(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return router.ReviewContext(context.Background(), arRequest)
+}
+
+// ReviewContext dispatches the admission request to the reviewer of the first matching route. The context is passed on
+// if the reviewer implements the ContextReviewer interface.
+func (router *Router) ReviewContext(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	reviewer := router.fallback
+	for _, route := range router.routes {
+		if route.matcher.Match(arRequest) {
+			reviewer = route.reviewer
+			break
+		}
+	}
+	if reviewer == nil {
+		return kindMismatchResult(ctx, router.fallbackPolicy, arRequest).admissionResponse(arRequest.UID)
+	}
+	if contextReviewer, ok := reviewer.(ContextReviewer); ok {
+		return contextReviewer.ReviewContext(ctx, arRequest)
+	}
+	return reviewer.Review(arRequest)
+}
+
+func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Handle(router, w, r)
+}
@@ -0,0 +1,63 @@
+package admissionreview_test
+
+import (
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type otherDataType struct {
+	Other string `json:"other,omitempty"`
+}
+
+var otherGroupVersionKind = &metav1.GroupVersionKind{
+	Group:   "apps",
+	Version: "v1",
+	Kind:    "Deployment",
+}
+
+var arRequestOther = &admissionv1.AdmissionRequest{
+	UID:    "456",
+	Kind:   *otherGroupVersionKind,
+	Object: runtime.RawExtension{Raw: []byte("{\"other\":\"abc\"}")},
+}
+
+func TestRouter(t *testing.T) {
+	var receivedOther *otherDataType
+	router := admissionreview.NewRouter().
+		RouteKinds(admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+			return &admissionreview.ValidateResult{Allow: false, Status: status}
+		}, groupVersionKind), groupVersionKind).
+		RouteKinds(admissionreview.ValidatingReviewer(func(request *otherDataType) *admissionreview.ValidateResult {
+			receivedOther = request
+			return &admissionreview.ValidateResult{Allow: true}
+		}, otherGroupVersionKind), otherGroupVersionKind)
+
+	assert.Equal(t, arResponseFailure, router.Review(arRequest))
+	testResult := router.Review(arRequestOther)
+	assert.True(t, testResult.Allowed)
+	assert.Equal(t, arRequestOther.UID, testResult.UID)
+	assert.Equal(t, &otherDataType{Other: "abc"}, receivedOther)
+}
+
+func TestRouterFallback(t *testing.T) {
+	router := admissionreview.NewRouter().
+		RouteKinds(admissionreview.ValidatingReviewer(func(request *otherDataType) *admissionreview.ValidateResult {
+			return &admissionreview.ValidateResult{Allow: true}
+		}, otherGroupVersionKind), otherGroupVersionKind)
+	assert.True(t, router.Review(arRequest).Allowed)
+
+	router.FallbackPolicy(admissionreview.KindMismatchDeny)
+	testResult := router.Review(arRequest)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, arRequest.UID, testResult.UID)
+
+	router.Fallback(admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: false, Status: status}
+	}, groupVersionKind))
+	assert.Equal(t, arResponseFailure, router.Review(arRequest))
+}
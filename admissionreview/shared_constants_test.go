@@ -41,3 +41,10 @@ var arResponseMutatingSuccess = &admissionv1.AdmissionResponse{
 	PatchType: &patchType,
 	Patch:     dataPatch,
 }
+var arRequestUpdate = &admissionv1.AdmissionRequest{
+	UID:       arRequest.UID,
+	Kind:      *groupVersionKind,
+	Operation: admissionv1.Update,
+	Object:    runtime.RawExtension{Raw: dataMutated},
+	OldObject: runtime.RawExtension{Raw: data},
+}
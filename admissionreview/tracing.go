@@ -0,0 +1,81 @@
+package admissionreview
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// tracerName is the instrumentation name of the tracer of this package.
+const tracerName = "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+
+// Names of the spans created by the handler and the reviewers of this package.
+const (
+	// spanReview is the span of the whole admission review started by the handler.
+	spanReview = "AdmissionReview"
+	// spanDecode is the child span for decoding the AdmissionReview from the HTTP request.
+	spanDecode = "Decode"
+	// spanDecodeObject is the child span for unmarshalling the objects of the admission request in the reviewers.
+	spanDecodeObject = "DecodeObject"
+	// spanReviewFunction is the child span for the call of the user function, e.g. the ResourceValidator.
+	spanReviewFunction = "ReviewFunction"
+	// spanPatchDiff is the child span for the construction and the checks of the JSON Patch.
+	spanPatchDiff = "PatchDiff"
+	// spanEncode is the child span for encoding the AdmissionReview response.
+	spanEncode = "Encode"
+)
+
+// noopTracer is used if no tracer provider has been configured.
+var noopTracer = trace.NewNoopTracerProvider().Tracer(tracerName)
+
+// tracerContextKey is the context key for the tracer of the handler.
+type tracerContextKey struct{}
+
+// withTracer returns a copy of the context that carries the tracer.
+func withTracer(ctx context.Context, tracer trace.Tracer) context.Context {
+	return context.WithValue(ctx, tracerContextKey{}, tracer)
+}
+
+// startSpan starts a child span of the context via the tracer carried by the context. Tracing is disabled if the context carries no tracer.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	tracer, ok := ctx.Value(tracerContextKey{}).(trace.Tracer)
+	if !ok {
+		tracer = noopTracer
+	}
+	return tracer.Start(ctx, name)
+}
+
+// setRequestAttributes sets the attributes that identify the admission request on the span.
+func setRequestAttributes(span trace.Span, arRequest *admissionv1.AdmissionRequest) {
+	if arRequest == nil {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("admission.uid", string(arRequest.UID)),
+		attribute.String("admission.group", arRequest.Kind.Group),
+		attribute.String("admission.version", arRequest.Kind.Version),
+		attribute.String("admission.kind", arRequest.Kind.Kind),
+		attribute.String("admission.operation", string(arRequest.Operation)),
+	)
+}
+
+// setResponseAttributes sets the attributes that describe the decision on the span.
+func setResponseAttributes(span trace.Span, response *admissionv1.AdmissionResponse) {
+	if response == nil {
+		return
+	}
+	span.SetAttributes(
+		attribute.Bool("admission.allowed", response.Allowed),
+		attribute.Int("admission.code", int(responseCode(response))),
+		attribute.Int("admission.patch_operations", patchOperationCount(response.Patch)),
+	)
+}
+
+// recordError records the error on the span and marks the span as failed.
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,74 @@
+package admissionreview_test
+
+import (
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandlerTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	arRequestPod := &admissionv1.AdmissionRequest{
+		UID:       "123",
+		Kind:      *podGroupVersionKind,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: podRaw},
+	}
+	reviewer := admissionreview.RequestMutatingReviewer(func(meta *admissionreview.RequestMeta, request *corev1.Pod) (*admissionreview.ValidateResult, *admissionreview.Patch[corev1.Pod]) {
+		response := request.DeepCopy()
+		response.Labels = map[string]string{"tier": "backend"}
+		return &admissionreview.ValidateResult{Allow: true}, &admissionreview.Patch[corev1.Pod]{Request: request, Response: response}
+	}, admissionreview.WithKinds(podGroupVersionKind))
+	handler := admissionreview.NewHandler(reviewer, admissionreview.WithTracerProvider(tracerProvider), admissionreview.WithLogger(admissionreview.NopLogger))
+	resp := serveAdmissionReview(t, handler, arRequestPod)
+	require.True(t, resp.Response.Allowed)
+
+	spans := exporter.GetSpans()
+	spansByName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		spansByName[span.Name] = span
+	}
+	require.Len(t, spansByName, 6)
+	root, ok := spansByName["AdmissionReview"]
+	require.True(t, ok)
+	for _, name := range []string{"Decode", "DecodeObject", "ReviewFunction", "PatchDiff", "Encode"} {
+		span, ok := spansByName[name]
+		require.True(t, ok, name)
+		assert.Equal(t, root.SpanContext.TraceID(), span.SpanContext.TraceID(), name)
+		assert.Equal(t, root.SpanContext.SpanID(), span.Parent.SpanID(), name)
+	}
+	assert.Subset(t, root.Attributes, []attribute.KeyValue{
+		attribute.String("admission.uid", "123"),
+		attribute.String("admission.kind", "Pod"),
+		attribute.String("admission.operation", "CREATE"),
+		attribute.Bool("admission.allowed", true),
+		attribute.Int("admission.code", 200),
+		attribute.Int("admission.patch_operations", 1),
+	})
+}
+
+func TestHandlerTracingDecodeError(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true}
+	}, groupVersionKind)
+	handler := admissionreview.NewHandler(reviewer,
+		admissionreview.WithTracerProvider(tracerProvider), admissionreview.WithLogger(admissionreview.NopLogger))
+	serveRawAdmissionReview(t, handler, "invalid")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.NotEmpty(t, span.Events, span.Name)
+	}
+}
@@ -0,0 +1,62 @@
+package admissionreview
+
+import (
+	"context"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// ResourceTransitionValidator receives the previous state of the resource (oldRequest) as well as the requested state (request).
+// oldRequest is nil for CREATE operations, request is nil for DELETE operations.
+// Errors should be handled internally and modify the resulting ValidateResult accordingly.
+type ResourceTransitionValidator[T any] func(oldRequest *T, request *T) *ValidateResult
+
+// ResourceTransitionMutater receives the previous state of the resource (oldRequest) as well as the requested state (request).
+// oldRequest is nil for CREATE operations, request is nil for DELETE operations.
+// The returned Patch has the same semantics as for the ResourceMutater.
+type ResourceTransitionMutater[T any] func(oldRequest *T, request *T) (*ValidateResult, *Patch[T])
+
+// TransitionValidatingReviewer is the implementation of the ReviewerHandler interface for validations that depend on the previous state
+// of the resource, e.g. immutability rules or checks on the object being deleted. Requests that are not selected
+// via the WithKinds or WithMatcher options are handled without calling the validator, see WithKindMismatchPolicy.
+func TransitionValidatingReviewer[T any](validator ResourceTransitionValidator[T], opts ...ReviewerOption) ReviewerHandler {
+	options := newReviewerOptions(opts)
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if skipValidate := options.skip(ctx, arRequest); skipValidate != nil {
+			return skipValidate.admissionResponse(arRequest.UID)
+		}
+		_, span := startSpan(ctx, spanDecodeObject)
+		oldRequest, request, warnings, skipValidate := unmarshallTransition[T](arRequest, options.strictDecoding)
+		span.End()
+		if skipValidate != nil {
+			return skipValidate.admissionResponse(arRequest.UID)
+		}
+		_, span = startSpan(ctx, spanReviewFunction)
+		defer span.End()
+		return withWarnings(validator(oldRequest, request).admissionResponse(arRequest.UID), warnings)
+	})
+}
+
+// TransitionMutatingReviewer is the implementation of the ReviewerHandler interface for mutations that depend on the previous state
+// of the resource. Requests that are not selected via the WithKinds or WithMatcher options are handled
+// without calling the mutater, see WithKindMismatchPolicy. Otherwise, a JSON Patch is constructed from the result of the mutater and wrapped into an admissionResponse.
+func TransitionMutatingReviewer[T any](mutater ResourceTransitionMutater[T], opts ...ReviewerOption) ReviewerHandler {
+	options := newReviewerOptions(opts)
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if skipMutate := options.skip(ctx, arRequest); skipMutate != nil {
+			return skipMutate.admissionResponse(arRequest.UID)
+		}
+		_, span := startSpan(ctx, spanDecodeObject)
+		oldRequest, request, warnings, skipMutate := unmarshallTransition[T](arRequest, options.strictDecoding)
+		span.End()
+		if skipMutate != nil {
+			return skipMutate.admissionResponse(arRequest.UID)
+		}
+		_, span = startSpan(ctx, spanReviewFunction)
+		result, patches := mutater(oldRequest, request)
+		span.End()
+		remutate := patchRemutater(func(request *T) (*ValidateResult, *Patch[T]) {
+			return mutater(oldRequest, request)
+		})
+		return withWarnings(patchResponse(ctx, arRequest.UID, arRequest.Object.Raw, options, result, patches, remutate), warnings)
+	})
+}
@@ -0,0 +1,67 @@
+package admissionreview_test
+
+import (
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestTransitionValidatingReviewUpdate(t *testing.T) {
+	var receivedOld, received *dataType
+	resourceValidatorMock := func(oldRequest *dataType, request *dataType) *admissionreview.ValidateResult {
+		receivedOld, received = oldRequest, request
+		return &admissionreview.ValidateResult{
+			Allow:  false,
+			Status: status,
+		}
+	}
+	reviewer := admissionreview.TransitionValidatingReviewer(resourceValidatorMock, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequestUpdate)
+	assert.Equal(t, arResponseFailure, testResult)
+	assert.Equal(t, &dataType{Test: "123"}, receivedOld)
+	assert.Equal(t, &dataType{Test: "123", Test2: "234"}, received)
+}
+
+func TestTransitionValidatingReviewDelete(t *testing.T) {
+	var receivedOld, received *dataType
+	resourceValidatorMock := func(oldRequest *dataType, request *dataType) *admissionreview.ValidateResult {
+		receivedOld, received = oldRequest, request
+		return &admissionreview.ValidateResult{
+			Allow:  false,
+			Status: status,
+		}
+	}
+	arRequestDelete := &admissionv1.AdmissionRequest{
+		UID:       arRequest.UID,
+		Kind:      *groupVersionKind,
+		Operation: admissionv1.Delete,
+		OldObject: runtime.RawExtension{Raw: data},
+	}
+	reviewer := admissionreview.TransitionValidatingReviewer(resourceValidatorMock, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequestDelete)
+	assert.Equal(t, arResponseFailure, testResult)
+	assert.Equal(t, &dataType{Test: "123"}, receivedOld)
+	assert.Nil(t, received)
+}
+
+func TestTransitionMutatingReviewAllowed(t *testing.T) {
+	resourceMutaterMock := func(oldRequest *dataType, request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		// keep test2 from the old object
+		response := *request
+		response.Test2 = oldRequest.Test2
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[dataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	arRequestReverse := arRequestUpdate.DeepCopy()
+	arRequestReverse.Object, arRequestReverse.OldObject = arRequestUpdate.OldObject, arRequestUpdate.Object
+	reviewer := admissionreview.TransitionMutatingReviewer(resourceMutaterMock, admissionreview.WithKinds(groupVersionKind))
+	testResult := reviewer.Review(arRequestReverse)
+	assert.Equal(t, arResponseMutatingSuccess, testResult)
+}
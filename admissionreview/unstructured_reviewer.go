@@ -0,0 +1,39 @@
+package admissionreview
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// UnstructuredValidatingReviewer is the ValidatingReviewer for resources without Go types, e.g. third-party CRDs.
+// The request object is decoded into an unstructured.Unstructured, nested fields can be accessed via NestedField or the unstructured.Nested* functions.
+// The other reviewer variants can be used with unstructured.Unstructured as type parameter in the same way.
+func UnstructuredValidatingReviewer(validator ResourceValidator[unstructured.Unstructured], compatibleGroupVersionKinds ...*metav1.GroupVersionKind) ReviewerHandler {
+	return ValidatingReviewer(validator, compatibleGroupVersionKinds...)
+}
+
+// UnstructuredMutatingReviewer is the MutatingReviewer for resources without Go types, e.g. third-party CRDs.
+// The JSON Patch is constructed the same way as for typed resources, NewUnstructuredPatch provides a Patch that can be modified in place.
+func UnstructuredMutatingReviewer(mutater ResourceMutater[unstructured.Unstructured], compatibleGroupVersionKinds ...*metav1.GroupVersionKind) ReviewerHandler {
+	return MutatingReviewer(mutater, compatibleGroupVersionKinds...)
+}
+
+// NewUnstructuredPatch returns a Patch whose Response is a deep copy of the request. The Response can be modified,
+// e.g. via unstructured.SetNestedField, without affecting the request.
+func NewUnstructuredPatch(request *unstructured.Unstructured) *Patch[unstructured.Unstructured] {
+	return &Patch[unstructured.Unstructured]{
+		Request:  request,
+		Response: request.DeepCopy(),
+	}
+}
+
+// NestedField returns the value of the nested field of the unstructured object and whether it has been found with the expected type.
+// It complements the unstructured.Nested* functions with a type parameter, e.g. NestedField[int64](obj, "spec", "replicas").
+func NestedField[V any](obj *unstructured.Unstructured, fields ...string) (value V, found bool) {
+	raw, found, err := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	if err != nil || !found {
+		return value, false
+	}
+	value, found = raw.(V)
+	return value, found
+}
@@ -0,0 +1,52 @@
+package admissionreview_test
+
+import (
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var crdGroupVersionKind = &metav1.GroupVersionKind{
+	Group:   "example.com",
+	Version: "v1",
+	Kind:    "Widget",
+}
+
+var arRequestCrd = &admissionv1.AdmissionRequest{
+	UID:  "789",
+	Kind: *crdGroupVersionKind,
+	Object: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"test"},"spec":{"replicas":3,"size":"large"}}`),
+	},
+}
+
+func TestUnstructuredValidatingReview(t *testing.T) {
+	reviewer := admissionreview.UnstructuredValidatingReviewer(func(request *unstructured.Unstructured) *admissionreview.ValidateResult {
+		replicas, found := admissionreview.NestedField[int64](request, "spec", "replicas")
+		assert.True(t, found)
+		_, found = admissionreview.NestedField[string](request, "spec", "replicas")
+		assert.False(t, found)
+		return &admissionreview.ValidateResult{Allow: replicas <= 2, Status: status}
+	}, crdGroupVersionKind)
+	testResult := reviewer.Review(arRequestCrd)
+	assert.False(t, testResult.Allowed)
+	assert.Equal(t, arRequestCrd.UID, testResult.UID)
+}
+
+func TestUnstructuredMutatingReview(t *testing.T) {
+	reviewer := admissionreview.UnstructuredMutatingReviewer(func(request *unstructured.Unstructured) (*admissionreview.ValidateResult, *admissionreview.Patch[unstructured.Unstructured]) {
+		patch := admissionreview.NewUnstructuredPatch(request)
+		err := unstructured.SetNestedField(patch.Response.Object, "small", "spec", "size")
+		require.NoError(t, err)
+		return &admissionreview.ValidateResult{Allow: true}, patch
+	}, crdGroupVersionKind)
+	testResult := reviewer.Review(arRequestCrd)
+	assert.True(t, testResult.Allowed)
+	assert.JSONEq(t, `[{"op":"replace","path":"/spec/size","value":"small"}]`, string(testResult.Patch))
+}
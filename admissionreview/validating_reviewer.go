@@ -1,6 +1,7 @@
 package admissionreview
 
 import (
+	"context"
 	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -13,13 +14,25 @@ type ValidateResult struct {
 	Status *metav1.Status
 	// Allow determines whether to allow the given API request at all.
 	Allow bool
+	// Warnings are returned to the requesting API client, e.g. to announce policies that are not yet enforced.
+	// +optional
+	Warnings []string
+	// AuditAnnotations are added to the audit log of the API server, the keys are prefixed by the API server with the webhook name.
+	// +optional
+	AuditAnnotations map[string]string
 }
 
 func (result *ValidateResult) admissionResponse(uid types.UID) *admissionv1.AdmissionResponse {
+	if result == nil {
+		// review functions that return no result are handled by safeReview
+		return nil
+	}
 	return &admissionv1.AdmissionResponse{
-		UID:     uid,
-		Allowed: result.Allow,
-		Result:  result.Status,
+		UID:              uid,
+		Allowed:          result.Allow,
+		Result:           result.Status,
+		Warnings:         result.Warnings,
+		AuditAnnotations: result.AuditAnnotations,
 	}
 }
 
@@ -29,16 +42,19 @@ func (result *ValidateResult) admissionResponse(uid types.UID) *admissionv1.Admi
 type ResourceValidator[T any] func(request *T) *ValidateResult
 
 // ValidatingReviewer is the implementation of the ReviewerHandler interface. Checks the GroupVersionKind of the receives request
-// against what the given reviewer.Modifier supports. A miss match will result in a non-modifying response and
-// the allow value set to the value given by reviewer.AllowOnModifierMiss.
-// Otherwise the Patch function of the Modifier interface is called, a JSON Patch is constructed from the result
-// and wrapped into an admissionResponse.
+// against the compatibleGroupVersionKinds. A miss match will result in a response that allows the request.
+// Otherwise the validator is called and the result wrapped into an admissionResponse.
+// Use RequestValidatingReviewer with the WithKindMismatchPolicy option to deny requests for incompatible kinds instead.
 func ValidatingReviewer[T any](validator ResourceValidator[T], compatibleGroupVersionKinds ...*metav1.GroupVersionKind) ReviewerHandler {
-	return ReviewFunc(func(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return ContextReviewFunc(func(ctx context.Context, arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		_, span := startSpan(ctx, spanDecodeObject)
 		request, skipValidate := UnmarshallAdmissionRequest[T](arRequest.Object.Raw, compatibleGroupVersionKinds, &arRequest.Kind)
+		span.End()
 		if skipValidate != nil {
 			return skipValidate.admissionResponse(arRequest.UID)
 		}
+		_, span = startSpan(ctx, spanReviewFunction)
+		defer span.End()
 		return validator(request).admissionResponse(arRequest.UID)
 	})
 }
@@ -18,3 +18,20 @@ func TestValidatingReview(t *testing.T) {
 	testResult := reviewer.Review(arRequest)
 	assert.Equal(t, arResponseFailure, testResult)
 }
+
+func TestValidatingReviewWarnings(t *testing.T) {
+	warnings := []string{"label test will be mandatory"}
+	auditAnnotations := map[string]string{"policy": "warn"}
+	resourceValidatorMock := func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{
+			Allow:            true,
+			Warnings:         warnings,
+			AuditAnnotations: auditAnnotations,
+		}
+	}
+	reviewer := admissionreview.ValidatingReviewer(resourceValidatorMock, groupVersionKind)
+	testResult := reviewer.Review(arRequest)
+	assert.True(t, testResult.Allowed)
+	assert.Equal(t, warnings, testResult.Warnings)
+	assert.Equal(t, auditAnnotations, testResult.AuditAnnotations)
+}
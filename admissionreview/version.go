@@ -0,0 +1,111 @@
+package admissionreview
+
+import (
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// supportedAdmissionReviewVersions are the API versions of AdmissionReview objects supported by Handle.
+var supportedAdmissionReviewVersions = []string{
+	admissionv1.SchemeGroupVersion.String(),
+	admissionv1beta1.SchemeGroupVersion.String(),
+}
+
+// unmarshallAdmissionReview unmarshalls the raw AdmissionReview according to its API version and converts it to admission.k8s.io/v1 if required.
+// The TypeMeta of the returned AdmissionReview still holds the original API version. An absent API version is treated as admission.k8s.io/v1.
+func unmarshallAdmissionReview(rawReview []byte) (*admissionv1.AdmissionReview, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(rawReview, &typeMeta); err != nil {
+		return nil, err
+	}
+	switch typeMeta.APIVersion {
+	case "", admissionv1.SchemeGroupVersion.String():
+		var arReview admissionv1.AdmissionReview
+		if err := json.Unmarshal(rawReview, &arReview); err != nil {
+			return nil, err
+		}
+		return &arReview, nil
+	case admissionv1beta1.SchemeGroupVersion.String():
+		var arReview admissionv1beta1.AdmissionReview
+		if err := json.Unmarshal(rawReview, &arReview); err != nil {
+			return nil, err
+		}
+		return &admissionv1.AdmissionReview{
+			TypeMeta: arReview.TypeMeta,
+			Request:  convertRequestFromV1beta1(arReview.Request),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported AdmissionReview API version %s, supported are %v", typeMeta.APIVersion, supportedAdmissionReviewVersions)
+	}
+}
+
+// NewAdmissionReviewResponse wraps the response into an AdmissionReview of the given API version, ready to be encoded as JSON.
+// Pass the API version of the AdmissionReview returned by GetAdmissionReviewFromHttp to reply to admission.k8s.io/v1beta1 requests
+// with an admission.k8s.io/v1beta1 response. An absent API version is treated as admission.k8s.io/v1.
+func NewAdmissionReviewResponse(apiVersion string, response *admissionv1.AdmissionResponse) any {
+	if apiVersion == admissionv1beta1.SchemeGroupVersion.String() {
+		return &admissionv1beta1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "AdmissionReview",
+				APIVersion: apiVersion,
+			},
+			Response: convertResponseToV1beta1(response),
+		}
+	}
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionReview",
+			APIVersion: admissionv1.SchemeGroupVersion.String(),
+		},
+		Response: response,
+	}
+}
+
+// convertRequestFromV1beta1 converts the admission.k8s.io/v1beta1 AdmissionRequest into its admission.k8s.io/v1 counterpart.
+func convertRequestFromV1beta1(request *admissionv1beta1.AdmissionRequest) *admissionv1.AdmissionRequest {
+	if request == nil {
+		return nil
+	}
+	return &admissionv1.AdmissionRequest{
+		UID:                request.UID,
+		Kind:               request.Kind,
+		Resource:           request.Resource,
+		SubResource:        request.SubResource,
+		RequestKind:        request.RequestKind,
+		RequestResource:    request.RequestResource,
+		RequestSubResource: request.RequestSubResource,
+		Name:               request.Name,
+		Namespace:          request.Namespace,
+		Operation:          admissionv1.Operation(request.Operation),
+		UserInfo:           request.UserInfo,
+		Object:             request.Object,
+		OldObject:          request.OldObject,
+		DryRun:             request.DryRun,
+		Options:            request.Options,
+	}
+}
+
+// convertResponseToV1beta1 converts the admission.k8s.io/v1 AdmissionResponse into its admission.k8s.io/v1beta1 counterpart.
+func convertResponseToV1beta1(response *admissionv1.AdmissionResponse) *admissionv1beta1.AdmissionResponse {
+	if response == nil {
+		return nil
+	}
+	var patchType *admissionv1beta1.PatchType
+	if response.PatchType != nil {
+		converted := admissionv1beta1.PatchType(*response.PatchType)
+		patchType = &converted
+	}
+	return &admissionv1beta1.AdmissionResponse{
+		UID:              response.UID,
+		Allowed:          response.Allowed,
+		Result:           response.Result,
+		Patch:            response.Patch,
+		PatchType:        patchType,
+		AuditAnnotations: response.AuditAnnotations,
+		Warnings:         response.Warnings,
+	}
+}
@@ -0,0 +1,92 @@
+package admissionreview_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionreview "github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandleV1beta1(t *testing.T) {
+	resourceMutaterMock := func(request *dataType) (*admissionreview.ValidateResult, *admissionreview.Patch[dataType]) {
+		response := *request
+		response.Test2 = "234"
+		return &admissionreview.ValidateResult{
+				Allow: true,
+			}, &admissionreview.Patch[dataType]{
+				Request:  request,
+				Response: &response,
+			}
+	}
+	reviewer := admissionreview.MutatingReviewer(resourceMutaterMock, groupVersionKind)
+	arReview := &admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionReview",
+			APIVersion: admissionv1beta1.SchemeGroupVersion.String(),
+		},
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       arRequest.UID,
+			Kind:      *groupVersionKind,
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: data},
+		},
+	}
+	w := serveRawAdmissionReview(t, reviewer, arReview)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	var resp admissionv1beta1.AdmissionReview
+	err := json.NewDecoder(w.Result().Body).Decode(&resp)
+	require.NoError(t, err)
+	require.Equal(t, admissionv1beta1.SchemeGroupVersion.String(), resp.APIVersion)
+	require.Equal(t, "AdmissionReview", resp.Kind)
+	require.Equal(t, arRequest.UID, resp.Response.UID)
+	require.True(t, resp.Response.Allowed)
+	require.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *resp.Response.PatchType)
+	require.Equal(t, dataPatch, resp.Response.Patch)
+}
+
+func TestHandleUnsupportedVersion(t *testing.T) {
+	reviewer := admissionreview.ValidatingReviewer(func(request *dataType) *admissionreview.ValidateResult {
+		return &admissionreview.ValidateResult{Allow: true}
+	}, groupVersionKind)
+	arReview := &admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionReview",
+			APIVersion: "admission.k8s.io/v2",
+		},
+	}
+	w := serveRawAdmissionReview(t, reviewer, arReview)
+	require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+}
+
+// serveRawAdmissionReview serializes the admission review, calls the handler and returns the recorded response
+func serveRawAdmissionReview(t *testing.T, handler http.Handler, arReview any) *httptest.ResponseRecorder {
+	body, err := json.Marshal(arReview)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	require.NoError(t, err)
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestNewAdmissionReviewResponse(t *testing.T) {
+	response := &admissionv1.AdmissionResponse{UID: arRequest.UID, Allowed: true}
+	v1beta1Review, ok := admissionreview.NewAdmissionReviewResponse(admissionv1beta1.SchemeGroupVersion.String(), response).(*admissionv1beta1.AdmissionReview)
+	require.True(t, ok)
+	require.Equal(t, admissionv1beta1.SchemeGroupVersion.String(), v1beta1Review.APIVersion)
+	require.Equal(t, arRequest.UID, v1beta1Review.Response.UID)
+	require.True(t, v1beta1Review.Response.Allowed)
+
+	v1Review, ok := admissionreview.NewAdmissionReviewResponse("", response).(*admissionv1.AdmissionReview)
+	require.True(t, ok)
+	require.Equal(t, admissionv1.SchemeGroupVersion.String(), v1Review.APIVersion)
+	require.Equal(t, response, v1Review.Response)
+}
@@ -1,60 +1,49 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"net/http"
 	"os"
-	"strconv"
 
 	"github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/ngergs/k8s-adm-ctrl/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-var port = flag.Int("port", 8080, "Port on which the container listens for HTTP requests")
-var tlsCrt = flag.String("tls_crt", "", "Path to the tls certificate")
-var tlsPrivKey = flag.String("tls_priv_key", "", "Path to the tls private key")
-
 func main() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	flag.Parse()
-	if (*tlsCrt != "" && *tlsPrivKey == "") || (*tlsCrt == "" && *tlsPrivKey != "") {
-		log.Fatal().Msg("Inconsistent configuration. Either specify both the tls-crt and tls-priv-key or neither.")
+	config := server.DefaultConfig()
+	if err := config.RegisterFlags(flag.CommandLine); err != nil {
+		log.Fatal().Err(err).Msg("Invalid environment configuration")
 	}
-	setupHttpHandles()
-	serveHttp()
-}
+	flag.Parse()
 
-// handleHealthCheck provides a simple health handle that always returns HTTP 200 for GET requests.
-func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	srv, err := setupServer(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup the server")
+	}
+	if err := srv.Run(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Server failed")
 	}
-	w.WriteHeader(http.StatusOK)
-}
-
-// setupHttpHandles wires the relevant http handles together.
-// For this example we just provide a health endpoint and configure our example NamespaceLabelModifier.
-func setupHttpHandles() {
-	mutater := &namespaceLabelMutater{}
-	// Adjust this to place your custom handlers
-	http.Handle("/mutate", admissionreview.MutatingReviewer(mutater.Patch, compatibleGroupVersionKind))
-	http.Handle("/validate", admissionreview.ValidatingReviewer(mutater.Validate, compatibleGroupVersionKind))
-	http.HandleFunc("/health", handleHealthCheck)
 }
 
-// starts the HTTP server. TLS is activated if tlsCrt is set.
-func serveHttp() {
-	var err error
-	if *tlsCrt != "" {
-		log.Info().Msg("Serving HTTPS")
-		err = http.ListenAndServeTLS(":"+strconv.Itoa(*port), *tlsCrt, *tlsPrivKey, nil)
-	} else {
-		log.Info().Msg("Serving HTTP")
-		err = http.ListenAndServe(":"+strconv.Itoa(*port), nil)
-	}
+// setupServer wires the relevant http handles together.
+// For this example we provide a metrics endpoint alongside the health endpoints of the server and configure our example NamespaceLabelModifier.
+func setupServer(config *server.Config) (*server.Server, error) {
+	metrics, err := admissionreview.NewMetrics(prometheus.DefaultRegisterer)
 	if err != nil {
-		log.Fatal().Err(err).Msg("HTTP ListenAndServe failed")
+		return nil, err
 	}
+	mutater := &namespaceLabelMutater{}
+	// Adjust this to place your custom handlers
+	return server.New(config,
+		server.WithReviewer("/mutate", admissionreview.RequestMutatingReviewer(mutater.Patch, reviewerOptions...)),
+		server.WithReviewer("/validate", admissionreview.RequestValidatingReviewer(mutater.Validate, reviewerOptions...)),
+		server.WithHandlerOptions(admissionreview.WithMetrics(metrics)),
+		server.WithHandler("/metrics", promhttp.Handler()),
+	)
 }
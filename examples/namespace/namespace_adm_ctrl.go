@@ -18,6 +18,13 @@ var compatibleGroupVersionKind = &metav1.GroupVersionKind{
 	Kind:    "Namespace",
 }
 
+// reviewerOptions restrict the reviewers to namespaces. Requests for other kinds are denied,
+// as they indicate a misconfigured WebhookConfiguration.
+var reviewerOptions = []admissionreview.ReviewerOption{
+	admissionreview.WithKinds(compatibleGroupVersionKind),
+	admissionreview.WithKindMismatchPolicy(admissionreview.KindMismatchDenyAndLog),
+}
+
 var validationAllow = &admissionreview.ValidateResult{
 	Allow: true,
 }
@@ -30,13 +37,13 @@ var labelAbsentValidationError = &admissionreview.ValidateResult{
 	},
 }
 
-// namespaceLabelMutater is an example struct that implements the admissionreview.ResourceMutater and admissionreview.ResourceValidator
+// namespaceLabelMutater is an example struct that implements the admissionreview.ResourceRequestMutater and admissionreview.ResourceRequestValidator
 // functions. This implementation adds a namespaceLabelKey if absent. We only use a struct for illustrative purposes.
 // This very basic example would also work with just two pure functions.
 type namespaceLabelMutater struct{}
 
-// Patch implements the admissionreview.ResourceMutater interface and serves as an example implementation to add a namespaceLabelKey if absent.
-func (*namespaceLabelMutater) Patch(request *corev1.Namespace) (*admissionreview.ValidateResult, *admissionreview.Patch[corev1.Namespace]) {
+// Patch implements the admissionreview.ResourceRequestMutater interface and serves as an example implementation to add a namespaceLabelKey if absent.
+func (*namespaceLabelMutater) Patch(_ *admissionreview.RequestMeta, request *corev1.Namespace) (*admissionreview.ValidateResult, *admissionreview.Patch[corev1.Namespace]) {
 	if _, ok := request.Labels[namespaceNameLabelKey]; ok {
 		log.Info().Msgf("For namespace %v the %v label is present, no mutation applied.", request.Name, namespaceNameLabelKey)
 		return validationAllow, nil
@@ -57,8 +64,8 @@ func (*namespaceLabelMutater) Patch(request *corev1.Namespace) (*admissionreview
 	return validationAllow, patch
 }
 
-// Validate implements the admissionreview.ResourceValidator interface and serves as an example implementation to check whethera namespaceLabelKey is present.
-func (*namespaceLabelMutater) Validate(request *corev1.Namespace) *admissionreview.ValidateResult {
+// Validate implements the admissionreview.ResourceRequestValidator interface and serves as an example implementation to check whethera namespaceLabelKey is present.
+func (*namespaceLabelMutater) Validate(_ *admissionreview.RequestMeta, request *corev1.Namespace) *admissionreview.ValidateResult {
 	if _, ok := request.Labels[namespaceNameLabelKey]; !ok {
 		log.Info().Msgf("Request for namespace %v failed validation. The label %v is missing.", request.Name, namespaceNameLabelKey)
 		return labelAbsentValidationError
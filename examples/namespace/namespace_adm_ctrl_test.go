@@ -58,6 +58,18 @@ func TestMutationChange(t *testing.T) {
 	require.True(t, resp.Response.Allowed)
 }
 
+// TestKindMismatch checks that requests for other kinds are denied with a BadRequest status code
+func TestKindMismatch(t *testing.T) {
+	req := strings.Replace(requestValid, `"kind": "Namespace"`, `"kind": "Pod"`, 1)
+	require.NotEqual(t, requestValid, req)
+	resp := testValidation(t, req)
+	require.False(t, resp.Response.Allowed)
+	require.Equal(t, http.StatusBadRequest, int(resp.Response.Result.Code))
+	resp = testMutation(t, req)
+	require.False(t, resp.Response.Allowed)
+	require.Nil(t, resp.Response.Patch)
+}
+
 // applyJsonPatch applies a RFC6902 JSON Patch onto the .Request.Object of the input JSON encoded AdmissionReview
 func applyJsonPatch(t *testing.T, admReqStr string, patch jsonpatch.Patch) string {
 	var admReq admissionv1.AdmissionReview
@@ -77,14 +89,14 @@ func applyJsonPatch(t *testing.T, admReqStr string, patch jsonpatch.Patch) strin
 // testValidation creates a namespace validation reviewer, calls it with the serialized request and returns the response
 func testValidation(t *testing.T, req string) *admissionv1.AdmissionReview {
 	adm := namespaceLabelMutater{}
-	rev := admissionreview.ValidatingReviewer(adm.Validate, compatibleGroupVersionKind)
+	rev := admissionreview.RequestValidatingReviewer(adm.Validate, reviewerOptions...)
 	return testAdmissionReview(t, req, rev)
 }
 
 // testMutation creates a namespace mutation reviewer, calls it with the serialized request and returns the response
 func testMutation(t *testing.T, req string) *admissionv1.AdmissionReview {
 	adm := namespaceLabelMutater{}
-	rev := admissionreview.MutatingReviewer(adm.Patch, compatibleGroupVersionKind)
+	rev := admissionreview.RequestMutatingReviewer(adm.Patch, reviewerOptions...)
 	return testAdmissionReview(t, req, rev)
 }
 
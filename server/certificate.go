@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ngergs/k8s-adm-ctrl/admissionreview"
+)
+
+// CertificateLoader provides the TLS certificate from PEM files for tls.Config.GetCertificate and reloads it when the files change,
+// e.g. when cert-manager rotates the certificate of a mounted secret. Only new TLS handshakes use the reloaded certificate,
+// established connections are not affected.
+type CertificateLoader struct {
+	certFile string
+	keyFile  string
+	logger   admissionreview.Logger
+	// certificate is the currently served certificate
+	certificate atomic.Pointer[tls.Certificate]
+	// mu guards the raw file contents of the current certificate
+	mu      sync.Mutex
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// NewCertificateLoader loads the certificate from the PEM encoded certificate and private key files.
+func NewCertificateLoader(certFile string, keyFile string, logger admissionreview.Logger) (*CertificateLoader, error) {
+	loader := &CertificateLoader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	if _, err := loader.Reload(); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// GetCertificate returns the current certificate, the signature matches tls.Config.GetCertificate.
+func (loader *CertificateLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return loader.certificate.Load(), nil
+}
+
+// Reload reads the files and swaps the certificate if their contents have changed. Returns whether the certificate has been swapped.
+// The current certificate is kept if the files cannot be read or do not contain a matching certificate and private key,
+// e.g. as only one of them has been updated yet.
+func (loader *CertificateLoader) Reload() (bool, error) {
+	certPEM, err := os.ReadFile(loader.certFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(loader.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the private key: %w", err)
+	}
+	loader.mu.Lock()
+	defer loader.mu.Unlock()
+	if bytes.Equal(certPEM, loader.certPEM) && bytes.Equal(keyPEM, loader.keyPEM) {
+		return false, nil
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse the certificate: %w", err)
+	}
+	loader.certificate.Store(&certificate)
+	loader.certPEM, loader.keyPEM = certPEM, keyPEM
+	return true, nil
+}
+
+// Watch reloads the certificate every interval until ctx is done. Failed reloads are logged and retried in the next interval.
+// Polling is used instead of file system notifications, as mounted secrets are updated by swapping symbolic links.
+func (loader *CertificateLoader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := loader.Reload()
+			if err != nil {
+				loader.logger.Log(admissionreview.LogLevelWarn, "Failed to reload the TLS certificate, keeping the current one", "error", err)
+				continue
+			}
+			if reloaded {
+				loader.logger.Log(admissionreview.LogLevelInfo, "Reloaded the TLS certificate", "file", loader.certFile)
+			}
+		}
+	}
+}
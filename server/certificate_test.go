@@ -0,0 +1,135 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/ngergs/k8s-adm-ctrl/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateLoaderReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+	loader, err := server.NewCertificateLoader(certFile, keyFile, admissionreview.NopLogger)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), serialNumber(t, loader))
+
+	reloaded, err := loader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeCertificate(t, certFile, keyFile, 2)
+	reloaded, err = loader.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, int64(2), serialNumber(t, loader))
+}
+
+func TestCertificateLoaderKeepsCertificateOnError(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+	loader, err := server.NewCertificateLoader(certFile, keyFile, admissionreview.NopLogger)
+	require.NoError(t, err)
+
+	// only the certificate has been rotated yet, so it does not match the private key
+	otherDir := t.TempDir()
+	writeCertificate(t, filepath.Join(otherDir, "tls.crt"), filepath.Join(otherDir, "tls.key"), 2)
+	certPEM, err := os.ReadFile(filepath.Join(otherDir, "tls.crt"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	_, err = loader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, int64(1), serialNumber(t, loader))
+
+	require.NoError(t, os.Remove(keyFile))
+	_, err = loader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, int64(1), serialNumber(t, loader))
+}
+
+func TestNewCertificateLoaderMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := server.NewCertificateLoader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), admissionreview.NopLogger)
+	assert.Error(t, err)
+}
+
+func TestServerReloadsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+	config := server.DefaultConfig()
+	config.TlsCrt, config.TlsPrivKey = certFile, keyFile
+	config.CertReloadInterval = 10 * time.Millisecond
+	config.ShutdownDelay = 0
+	srv, err := server.New(config, server.WithLogger(admissionreview.NopLogger))
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = srv.Serve(ctx, listener)
+	}()
+
+	address := listener.Addr().String()
+	require.Eventually(t, func() bool { return peerSerialNumber(address) == 1 }, time.Second, 10*time.Millisecond)
+	writeCertificate(t, certFile, keyFile, 2)
+	assert.Eventually(t, func() bool { return peerSerialNumber(address) == 2 }, time.Second, 10*time.Millisecond)
+}
+
+// writeCertificate writes a self-signed certificate with the given serial number and its private key PEM encoded to the files.
+func writeCertificate(t *testing.T, certFile string, keyFile string, serial int64) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func serialNumber(t *testing.T, loader *server.CertificateLoader) int64 {
+	certificate, err := loader.GetCertificate(nil)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	return parsed.SerialNumber.Int64()
+}
+
+// peerSerialNumber returns the serial number of the certificate served at the address, -1 if the handshake fails.
+func peerSerialNumber(address string) int64 {
+	conn, err := tls.Dial("tcp", address, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return -1
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
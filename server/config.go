@@ -0,0 +1,187 @@
+package server
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables that set the defaults of the flags registered via Config.RegisterFlags,
+// e.g. WEBHOOK_PORT for the port flag.
+const EnvPrefix = "WEBHOOK_"
+
+// Config holds the settings of the webhook Server.
+type Config struct {
+	// Port is the port the server listens on.
+	Port int
+	// TlsCrt is the path of the TLS certificate. TLS is disabled if empty.
+	TlsCrt string
+	// TlsPrivKey is the path of the TLS private key, has to be set if and only if TlsCrt is set.
+	TlsPrivKey string
+	// SelfSignedDNSNames enables a self-signed serving certificate for the DNS names, e.g. webhook-example.default.svc,
+	// as alternative to TlsCrt and TlsPrivKey. See EnsureSelfSignedCertificate.
+	SelfSignedDNSNames []string
+	// SelfSignedDir is the directory in which the self-signed CA and serving certificate are persisted, required for SelfSignedDNSNames.
+	SelfSignedDir string
+	// SelfSignedRenewInterval is the interval in which the self-signed certificate is checked for renewal, zero disables the renewal.
+	// The certificate is renewed 30 days before it expires, see EnsureSelfSignedCertificate.
+	SelfSignedRenewInterval time.Duration
+	// CertReloadInterval is the interval in which the TLS certificate files are checked for changes, zero disables the reloading.
+	CertReloadInterval time.Duration
+	// ReviewTimeout is the deadline of a single admission review, see admissionreview.WithTimeout.
+	// It should be below the timeoutSeconds of the WebhookConfiguration.
+	ReviewTimeout time.Duration
+	// ReadHeaderTimeout is the timeout for reading the headers of HTTP requests.
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout is the timeout for reading whole HTTP requests.
+	ReadTimeout time.Duration
+	// WriteTimeout is the timeout for writing HTTP responses.
+	WriteTimeout time.Duration
+	// IdleTimeout is the timeout for idle keep-alive connections.
+	IdleTimeout time.Duration
+	// ShutdownDelay is the time between the termination signal and the shutdown of the server. The readiness endpoint fails during the delay,
+	// so that the endpoint can be removed from the webhook service before the server stops accepting connections.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout is the maximum time to drain active connections during the shutdown.
+	ShutdownTimeout time.Duration
+	// LivenessPath is the path of the liveness endpoint.
+	LivenessPath string
+	// ReadinessPath is the path of the readiness endpoint.
+	ReadinessPath string
+}
+
+// DefaultConfig returns the default settings.
+func DefaultConfig() *Config {
+	return &Config{
+		Port:                    8080,
+		SelfSignedRenewInterval: time.Hour,
+		CertReloadInterval:      10 * time.Second,
+		ReviewTimeout:           9 * time.Second,
+		ReadHeaderTimeout:       5 * time.Second,
+		ReadTimeout:             10 * time.Second,
+		WriteTimeout:            15 * time.Second,
+		IdleTimeout:             120 * time.Second,
+		ShutdownDelay:           5 * time.Second,
+		ShutdownTimeout:         20 * time.Second,
+		LivenessPath:            "/health",
+		ReadinessPath:           "/ready",
+	}
+}
+
+// RegisterFlags registers flags for the settings on the flag set, e.g. flag.CommandLine. The defaults of the flags are the current values
+// of the config, overridden by the corresponding environment variables, e.g. WEBHOOK_TLS_CRT for the tls_crt flag.
+// Hence, flags take precedence over environment variables, which take precedence over the config values.
+func (config *Config) RegisterFlags(flagSet *flag.FlagSet) error {
+	var errs []error
+	intVar := func(p *int, name string, usage string) {
+		if err := intFromEnv(p, name); err != nil {
+			errs = append(errs, err)
+		}
+		flagSet.IntVar(p, name, *p, usage)
+	}
+	stringVar := func(p *string, name string, usage string) {
+		if value, ok := os.LookupEnv(envName(name)); ok {
+			*p = value
+		}
+		flagSet.StringVar(p, name, *p, usage)
+	}
+	stringSliceVar := func(p *[]string, name string, usage string) {
+		if value, ok := os.LookupEnv(envName(name)); ok {
+			*p = splitList(value)
+		}
+		flagSet.Func(name, usage, func(value string) error {
+			*p = splitList(value)
+			return nil
+		})
+	}
+	durationVar := func(p *time.Duration, name string, usage string) {
+		if err := durationFromEnv(p, name); err != nil {
+			errs = append(errs, err)
+		}
+		flagSet.DurationVar(p, name, *p, usage)
+	}
+	intVar(&config.Port, "port", "Port on which the container listens for HTTP requests")
+	stringVar(&config.TlsCrt, "tls_crt", "Path to the tls certificate")
+	stringVar(&config.TlsPrivKey, "tls_priv_key", "Path to the tls private key")
+	stringSliceVar(&config.SelfSignedDNSNames, "self_signed_dns_names", "Comma-separated dns names of a self-signed serving certificate, alternative to tls_crt and tls_priv_key")
+	stringVar(&config.SelfSignedDir, "self_signed_dir", "Directory in which the self-signed CA and serving certificate are persisted")
+	durationVar(&config.SelfSignedRenewInterval, "self_signed_renew_interval", "Interval in which the self-signed certificate is checked for renewal, zero disables the renewal")
+	durationVar(&config.CertReloadInterval, "cert_reload_interval", "Interval in which the tls certificate files are checked for changes, zero disables the reloading")
+	durationVar(&config.ReviewTimeout, "review_timeout", "Deadline for a single admission review, should be below the timeoutSeconds of the webhook configuration")
+	durationVar(&config.ReadHeaderTimeout, "read_header_timeout", "Timeout for reading the headers of HTTP requests")
+	durationVar(&config.ReadTimeout, "read_timeout", "Timeout for reading HTTP requests")
+	durationVar(&config.WriteTimeout, "write_timeout", "Timeout for writing HTTP responses")
+	durationVar(&config.IdleTimeout, "idle_timeout", "Timeout for idle keep-alive connections")
+	durationVar(&config.ShutdownDelay, "shutdown_delay", "Delay between the termination signal and the shutdown, the readiness endpoint fails meanwhile")
+	durationVar(&config.ShutdownTimeout, "shutdown_timeout", "Maximum time to drain active connections during the shutdown")
+	stringVar(&config.LivenessPath, "liveness_path", "Path of the liveness endpoint")
+	stringVar(&config.ReadinessPath, "readiness_path", "Path of the readiness endpoint")
+	return errors.Join(errs...)
+}
+
+// Validate checks the consistency of the settings.
+func (config *Config) Validate() error {
+	if (config.TlsCrt == "") != (config.TlsPrivKey == "") {
+		return errors.New("inconsistent configuration, either specify both the tls certificate and private key or neither")
+	}
+	if len(config.SelfSignedDNSNames) > 0 {
+		if config.TlsCrt != "" {
+			return errors.New("inconsistent configuration, either specify the tls certificate or self-signed dns names")
+		}
+		if config.SelfSignedDir == "" {
+			return errors.New("inconsistent configuration, the self-signed dns names require the self-signed directory")
+		}
+	}
+	if config.Port < 0 || config.Port > 65535 {
+		return fmt.Errorf("invalid port %d", config.Port)
+	}
+	return nil
+}
+
+// envName returns the name of the environment variable for the flag.
+func envName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(flagName)
+}
+
+// splitList splits the comma-separated value and drops empty entries.
+func splitList(value string) []string {
+	var result []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// intFromEnv sets p to the value of the environment variable of the flag if it is present.
+func intFromEnv(p *int, flagName string) error {
+	value, ok := os.LookupEnv(envName(flagName))
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid value of %s: %w", envName(flagName), err)
+	}
+	*p = parsed
+	return nil
+}
+
+// durationFromEnv sets p to the value of the environment variable of the flag if it is present.
+func durationFromEnv(p *time.Duration, flagName string) error {
+	value, ok := os.LookupEnv(envName(flagName))
+	if !ok {
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid value of %s: %w", envName(flagName), err)
+	}
+	*p = parsed
+	return nil
+}
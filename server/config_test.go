@@ -0,0 +1,47 @@
+package server_test
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/ngergs/k8s-adm-ctrl/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFlagsAndEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_PORT", "9443")
+	t.Setenv("WEBHOOK_REVIEW_TIMEOUT", "3s")
+	t.Setenv("WEBHOOK_TLS_CRT", "env.crt")
+	config := server.DefaultConfig()
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	require.NoError(t, config.RegisterFlags(flagSet))
+	require.NoError(t, flagSet.Parse([]string{"-tls_crt", "flag.crt", "-tls_priv_key", "flag.key"}))
+
+	assert.Equal(t, 9443, config.Port)
+	assert.Equal(t, 3*time.Second, config.ReviewTimeout)
+	assert.Equal(t, "flag.crt", config.TlsCrt)
+	assert.Equal(t, "flag.key", config.TlsPrivKey)
+	assert.Equal(t, server.DefaultConfig().IdleTimeout, config.IdleTimeout)
+	assert.NoError(t, config.Validate())
+}
+
+func TestConfigInvalidEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_SHUTDOWN_DELAY", "5")
+	config := server.DefaultConfig()
+	assert.Error(t, config.RegisterFlags(flag.NewFlagSet("test", flag.ContinueOnError)))
+}
+
+func TestConfigSelfSignedDNSNames(t *testing.T) {
+	t.Setenv("WEBHOOK_SELF_SIGNED_DNS_NAMES", "env.default.svc")
+	config := server.DefaultConfig()
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	require.NoError(t, config.RegisterFlags(flagSet))
+	assert.Equal(t, []string{"env.default.svc"}, config.SelfSignedDNSNames)
+	require.NoError(t, flagSet.Parse([]string{"-self_signed_dns_names", "webhook.default.svc, webhook.default.svc.cluster.local"}))
+	assert.Equal(t, []string{"webhook.default.svc", "webhook.default.svc.cluster.local"}, config.SelfSignedDNSNames)
+	assert.Error(t, config.Validate())
+	config.SelfSignedDir = "certs"
+	assert.NoError(t, config.Validate())
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package server
+
+import (
+	"sync"
+)
+
+// lockMutex serializes the generation within the process, as file locks are only supported on unix platforms.
+var lockMutex sync.Mutex
+
+// lockFile acquires a process-wide lock, the file is not locked on this platform. The returned function releases the lock.
+func lockFile(string) (func() error, error) {
+	lockMutex.Lock()
+	return func() error {
+		lockMutex.Unlock()
+		return nil
+	}, nil
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+// File names of the self-signed certificates in the directory, see EnsureSelfSignedCertificate.
+const (
+	SelfSignedCAFile   = "ca.crt"
+	SelfSignedCAKey    = "ca.key"
+	SelfSignedCertFile = "tls.crt"
+	SelfSignedKeyFile  = "tls.key"
+	// SelfSignedLockFile is locked during the generation, so that replicas sharing the directory do not generate different CAs.
+	SelfSignedLockFile = ".lock"
+)
+
+const (
+	// selfSignedCAValidity is the validity of a generated CA certificate
+	selfSignedCAValidity = 10 * 365 * 24 * time.Hour
+	// selfSignedCertValidity is the validity of a generated serving certificate
+	selfSignedCertValidity = 365 * 24 * time.Hour
+	// selfSignedRenewBefore is the remaining validity below which persisted certificates are regenerated
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+// SelfSignedCertificate references the files of a self-signed serving certificate and holds the bundle of the CA that signed it.
+type SelfSignedCertificate struct {
+	// CertFile is the path of the PEM encoded serving certificate.
+	CertFile string
+	// KeyFile is the path of the PEM encoded private key of the serving certificate.
+	KeyFile string
+	// CABundle is the PEM encoded CA certificate, to be set as caBundle of the clientConfig of the WebhookConfiguration.
+	CABundle []byte
+	// CAGenerated reports whether a new CA has been generated. The CA bundle of the WebhookConfiguration has to be updated in this case,
+	// admission requests fail until then.
+	CAGenerated bool
+}
+
+// EnsureSelfSignedCertificate provides a serving certificate for the DNS names signed by a self-signed CA, e.g. for webhook-example.default.svc.
+// The CA and the serving certificate are persisted in the directory and reused on subsequent calls as long as they are valid
+// for at least another 30 days. The serving certificate is also regenerated if it does not cover the DNS names. The CA is kept
+// if only the serving certificate is regenerated, so the CA bundle in the WebhookConfiguration stays valid.
+// The CA is valid for 10 years. Once it is renewed, which is reported via CAGenerated, the CA bundle of the WebhookConfiguration has to be updated.
+// All replicas of a webhook have to share the directory, otherwise each generates its own CA. The generation is guarded
+// by an exclusive lock on the SelfSignedLockFile in the directory (only within the process on non-unix platforms).
+func EnsureSelfSignedCertificate(dir string, dnsNames []string) (*SelfSignedCertificate, error) {
+	if len(dnsNames) == 0 {
+		return nil, errors.New("at least one dns name is required for the self-signed certificate")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the certificate directory: %w", err)
+	}
+	unlock, err := lockFile(filepath.Join(dir, SelfSignedLockFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the certificate directory: %w", err)
+	}
+	defer unlock()
+	// the files are read after acquiring the lock, so that a CA generated by another replica is reused
+	caFile, caKeyFile := filepath.Join(dir, SelfSignedCAFile), filepath.Join(dir, SelfSignedCAKey)
+	certFile, keyFile := filepath.Join(dir, SelfSignedCertFile), filepath.Join(dir, SelfSignedKeyFile)
+
+	ca, err := loadCertificate(caFile, caKeyFile)
+	renewCA := err != nil || !validFor(ca.Leaf, selfSignedRenewBefore)
+	if renewCA {
+		ca, err = generateCertificate(&x509.Certificate{
+			Subject:               pkix.Name{CommonName: "webhook-ca"},
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, selfSignedCAValidity, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the CA: %w", err)
+		}
+		if err = writeCertificate(ca, caFile, caKeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	cert, err := loadCertificate(certFile, keyFile)
+	if renewCA || err != nil || !validFor(cert.Leaf, selfSignedRenewBefore) || cert.Leaf.CheckSignatureFrom(ca.Leaf) != nil || !coversDNSNames(cert.Leaf, dnsNames) {
+		cert, err = generateCertificate(&x509.Certificate{
+			Subject:     pkix.Name{CommonName: dnsNames[0]},
+			DNSNames:    dnsNames,
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, selfSignedCertValidity, ca)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the serving certificate: %w", err)
+		}
+		if err = writeCertificate(cert, certFile, keyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SelfSignedCertificate{
+		CertFile:    certFile,
+		KeyFile:     keyFile,
+		CABundle:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate[0]}),
+		CAGenerated: renewCA,
+	}, nil
+}
+
+// SetMutatingCABundle sets the CA bundle in the clientConfig of all webhooks of the configuration.
+func SetMutatingCABundle(webhookConfig *admissionregistrationv1.MutatingWebhookConfiguration, caBundle []byte) {
+	for i := range webhookConfig.Webhooks {
+		webhookConfig.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+}
+
+// SetValidatingCABundle sets the CA bundle in the clientConfig of all webhooks of the configuration.
+func SetValidatingCABundle(webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration, caBundle []byte) {
+	for i := range webhookConfig.Webhooks {
+		webhookConfig.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+}
+
+// loadCertificate loads the certificate and private key files and parses the leaf certificate.
+func loadCertificate(certFile string, keyFile string) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// generateCertificate generates a new ECDSA P-256 key and certificate from the template. The certificate is self-signed if parent is nil.
+func generateCertificate(template *x509.Certificate, validity time.Duration, parent *tls.Certificate) (*tls.Certificate, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	// backdate to tolerate clock skew
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(validity)
+	parentCert, signer := template, any(privateKey)
+	if parent != nil {
+		parentCert, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &privateKey.PublicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey, Leaf: leaf}, nil
+}
+
+// writeCertificate persists the certificate and private key PEM encoded. The files are replaced atomically,
+// so a CertificateLoader never reads a partially written file.
+func writeCertificate(certificate *tls.Certificate, certFile string, keyFile string) error {
+	keyDER, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return fmt.Errorf("failed to write the private key: %w", err)
+	}
+	if err = writeFileAtomic(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0o644); err != nil {
+		return fmt.Errorf("failed to write the certificate: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes the data to a temporary file in the same directory and renames it to the target.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// validFor checks whether the certificate is valid now and for at least the given duration.
+func validFor(certificate *x509.Certificate, duration time.Duration) bool {
+	now := time.Now()
+	return now.After(certificate.NotBefore) && now.Add(duration).Before(certificate.NotAfter)
+}
+
+// coversDNSNames checks whether the certificate is valid for all DNS names.
+func coversDNSNames(certificate *x509.Certificate, dnsNames []string) bool {
+	for _, dnsName := range dnsNames {
+		if certificate.VerifyHostname(dnsName) != nil {
+			return false
+		}
+	}
+	return true
+}
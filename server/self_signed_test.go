@@ -0,0 +1,218 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/ngergs/k8s-adm-ctrl/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+func TestEnsureSelfSignedCertificate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "certs")
+	dnsNames := []string{"webhook-example.default.svc"}
+	selfSigned, err := server.EnsureSelfSignedCertificate(dir, dnsNames)
+	require.NoError(t, err)
+	verifyServingCertificate(t, selfSigned, dnsNames[0])
+	assert.True(t, selfSigned.CAGenerated)
+
+	// persisted certificates are reused
+	reused, err := server.EnsureSelfSignedCertificate(dir, dnsNames)
+	require.NoError(t, err)
+	assert.Equal(t, selfSigned.CABundle, reused.CABundle)
+	assert.False(t, reused.CAGenerated)
+	assert.Equal(t, readFile(t, selfSigned.CertFile), readFile(t, reused.CertFile))
+}
+
+func TestEnsureSelfSignedCertificateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	dnsNames := []string{"webhook-example.default.svc"}
+	results := make([]*server.SelfSignedCertificate, 32)
+	errs := make([]error, len(results))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = server.EnsureSelfSignedCertificate(dir, dnsNames)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	// the CA is generated once and shared, the persisted serving certificate is signed by it
+	generated := 0
+	for i, result := range results {
+		require.NoError(t, errs[i])
+		assert.Equal(t, results[0].CABundle, result.CABundle)
+		if result.CAGenerated {
+			generated++
+		}
+		verifyServingCertificate(t, result, dnsNames[0])
+	}
+	assert.Equal(t, 1, generated)
+}
+
+func TestEnsureSelfSignedCertificateChangedDNSNames(t *testing.T) {
+	dir := t.TempDir()
+	selfSigned, err := server.EnsureSelfSignedCertificate(dir, []string{"webhook-example.default.svc"})
+	require.NoError(t, err)
+	certPEM := readFile(t, selfSigned.CertFile)
+
+	// the serving certificate is regenerated while the CA is kept
+	dnsNames := []string{"webhook-example.default.svc", "webhook-example.default.svc.cluster.local"}
+	renewed, err := server.EnsureSelfSignedCertificate(dir, dnsNames)
+	require.NoError(t, err)
+	assert.Equal(t, selfSigned.CABundle, renewed.CABundle)
+	assert.NotEqual(t, certPEM, readFile(t, renewed.CertFile))
+	verifyServingCertificate(t, renewed, dnsNames[1])
+}
+
+func TestEnsureSelfSignedCertificateRenewal(t *testing.T) {
+	dir := t.TempDir()
+	dnsNames := []string{"webhook-example.default.svc"}
+	selfSigned, err := server.EnsureSelfSignedCertificate(dir, dnsNames)
+	require.NoError(t, err)
+	writeExpiringCertificate(t, dir, dnsNames, 42)
+
+	// the serving certificate expires within 30 days and is renewed, the CA is kept
+	renewed, err := server.EnsureSelfSignedCertificate(dir, dnsNames)
+	require.NoError(t, err)
+	assert.Equal(t, selfSigned.CABundle, renewed.CABundle)
+	assert.NotEqual(t, int64(42), fileSerialNumber(t, renewed.CertFile))
+	verifyServingCertificate(t, renewed, dnsNames[0])
+}
+
+func TestServerRenewsSelfSigned(t *testing.T) {
+	config := server.DefaultConfig()
+	config.SelfSignedDNSNames = []string{"webhook-example.default.svc"}
+	config.SelfSignedDir = t.TempDir()
+	config.SelfSignedRenewInterval = 10 * time.Millisecond
+	config.CertReloadInterval = 0
+	config.ShutdownDelay = 0
+	srv, err := server.New(config, server.WithLogger(admissionreview.NopLogger))
+	require.NoError(t, err)
+	caBundle := srv.CABundle()
+	certFile := filepath.Join(config.SelfSignedDir, server.SelfSignedCertFile)
+	writeExpiringCertificate(t, config.SelfSignedDir, config.SelfSignedDNSNames, 42)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = srv.Serve(ctx, listener)
+	}()
+
+	// the renewed certificate replaces the expiring one on disk and is served without a reload interval
+	address := listener.Addr().String()
+	assert.Eventually(t, func() bool {
+		serial := fileSerialNumber(t, certFile)
+		return serial != 42 && peerSerialNumber(address) == serial
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, caBundle, srv.CABundle())
+}
+
+func TestEnsureSelfSignedCertificateNoDNSNames(t *testing.T) {
+	_, err := server.EnsureSelfSignedCertificate(t.TempDir(), nil)
+	assert.Error(t, err)
+}
+
+func TestServerSelfSigned(t *testing.T) {
+	config := server.DefaultConfig()
+	config.SelfSignedDNSNames = []string{"webhook-example.default.svc"}
+	config.SelfSignedDir = t.TempDir()
+	srv, err := server.New(config, server.WithLogger(admissionreview.NopLogger))
+	require.NoError(t, err)
+	assert.NotEmpty(t, srv.CABundle())
+
+	config.TlsCrt, config.TlsPrivKey = "tls.crt", "tls.key"
+	_, err = server.New(config)
+	assert.Error(t, err)
+}
+
+func TestSetCABundle(t *testing.T) {
+	caBundle := []byte("ca")
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{Webhooks: make([]admissionregistrationv1.MutatingWebhook, 2)}
+	server.SetMutatingCABundle(mutating, caBundle)
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{Webhooks: make([]admissionregistrationv1.ValidatingWebhook, 2)}
+	server.SetValidatingCABundle(validating, caBundle)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, caBundle, mutating.Webhooks[i].ClientConfig.CABundle)
+		assert.Equal(t, caBundle, validating.Webhooks[i].ClientConfig.CABundle)
+	}
+}
+
+// verifyServingCertificate verifies that the serving certificate is valid for the dns name and signed by the CA bundle.
+func verifyServingCertificate(t *testing.T, selfSigned *server.SelfSignedCertificate, dnsName string) {
+	certificate, err := tls.LoadX509KeyPair(selfSigned.CertFile, selfSigned.KeyFile)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(selfSigned.CABundle))
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: dnsName, Roots: roots})
+	assert.NoError(t, err)
+}
+
+func readFile(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return data
+}
+
+// writeExpiringCertificate replaces the serving certificate in the directory with one that expires in a day, signed by the persisted CA.
+func writeExpiringCertificate(t *testing.T, dir string, dnsNames []string, serial int64) {
+	ca, err := tls.LoadX509KeyPair(filepath.Join(dir, server.SelfSignedCAFile), filepath.Join(dir, server.SelfSignedCAKey))
+	require.NoError(t, err)
+	caLeaf, err := x509.ParseCertificate(ca.Certificate[0])
+	require.NoError(t, err)
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caLeaf, &privateKey.PublicKey, ca.PrivateKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, server.SelfSignedKeyFile), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, server.SelfSignedCertFile), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+}
+
+// fileSerialNumber returns the serial number of the PEM encoded certificate file, -1 if it cannot be parsed.
+func fileSerialNumber(t *testing.T, certFile string) int64 {
+	block, _ := pem.Decode(readFile(t, certFile))
+	if block == nil {
+		return -1
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return -1
+	}
+	return certificate.SerialNumber.Int64()
+}
@@ -0,0 +1,283 @@
+// Package server provides a webhook server for admission reviewers with timeouts, graceful shutdown as well as liveness and readiness endpoints.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/rs/zerolog/log"
+)
+
+// Option configures optional behaviour of the Server.
+type Option func(*Server)
+
+// Server serves admission reviewers via HTTP(S). On SIGTERM or SIGINT the readiness endpoint starts to fail, and after the shutdown delay
+// the server stops accepting connections and drains the active ones, see Config.
+type Server struct {
+	config *Config
+	mux    *http.ServeMux
+	// handlerOptions are applied to all reviewers
+	handlerOptions []admissionreview.HandlerOption
+	// registrations are registered on the mux when the server is created
+	registrations []*registration
+	logger        admissionreview.Logger
+	// certificateLoader provides the TLS certificate, nil if TLS is disabled
+	certificateLoader *CertificateLoader
+	// caBundle is the CA bundle of the self-signed certificate, nil if not self-signed
+	caBundle atomic.Pointer[[]byte]
+	ready    atomic.Bool
+}
+
+// registration holds the path together with either a http.Handler or a reviewer and the handler options specific to it.
+type registration struct {
+	path           string
+	handler        http.Handler
+	reviewer       admissionreview.Reviewer
+	handlerOptions []admissionreview.HandlerOption
+}
+
+// WithReviewer serves the reviewer on the path. The reviewer is wrapped via admissionreview.NewHandler with the ReviewTimeout of the config,
+// the logger of the server, the options of WithHandlerOptions and the given handler options.
+func WithReviewer(path string, reviewer admissionreview.Reviewer, handlerOptions ...admissionreview.HandlerOption) Option {
+	return func(server *Server) {
+		server.registrations = append(server.registrations, &registration{path: path, reviewer: reviewer, handlerOptions: handlerOptions})
+	}
+}
+
+// WithHandler serves the http.Handler on the path, e.g. promhttp.Handler() for metrics.
+func WithHandler(path string, handler http.Handler) Option {
+	return func(server *Server) {
+		server.registrations = append(server.registrations, &registration{path: path, handler: handler})
+	}
+}
+
+// WithHandlerOptions applies the handler options to all reviewers, e.g. admissionreview.WithMetrics.
+func WithHandlerOptions(handlerOptions ...admissionreview.HandlerOption) Option {
+	return func(server *Server) {
+		server.handlerOptions = append(server.handlerOptions, handlerOptions...)
+	}
+}
+
+// WithLogger sets the logger of the server, which is passed on to the reviewers. Defaults to the global zerolog logger.
+func WithLogger(logger admissionreview.Logger) Option {
+	return func(server *Server) {
+		server.logger = logger
+	}
+}
+
+// New validates the config and sets up the server. Returns an error if several handlers are registered for the same path,
+// including the liveness and readiness paths of the config.
+func New(config *Config, opts ...Option) (*Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	server := &Server{
+		config: config,
+		mux:    http.NewServeMux(),
+		logger: admissionreview.NewZerologLogger(log.Logger),
+	}
+	for _, opt := range opts {
+		opt(server)
+	}
+	if err := server.registerHandlers(); err != nil {
+		return nil, err
+	}
+	certFile, keyFile := config.TlsCrt, config.TlsPrivKey
+	if len(config.SelfSignedDNSNames) > 0 {
+		selfSigned, err := EnsureSelfSignedCertificate(config.SelfSignedDir, config.SelfSignedDNSNames)
+		if err != nil {
+			return nil, err
+		}
+		certFile, keyFile = selfSigned.CertFile, selfSigned.KeyFile
+		server.caBundle.Store(&selfSigned.CABundle)
+	}
+	if certFile != "" {
+		certificateLoader, err := NewCertificateLoader(certFile, keyFile, server.logger)
+		if err != nil {
+			return nil, err
+		}
+		server.certificateLoader = certificateLoader
+	}
+	return server, nil
+}
+
+// registerHandlers registers the reviewers, handlers as well as the liveness and readiness endpoints on the mux.
+// The reviewers are wrapped via admissionreview.NewHandler.
+func (server *Server) registerHandlers() error {
+	registrations := append([]*registration{
+		{path: server.config.LivenessPath, handler: http.HandlerFunc(server.handleLiveness)},
+		{path: server.config.ReadinessPath, handler: http.HandlerFunc(server.handleReadiness)},
+	}, server.registrations...)
+	paths := make(map[string]bool, len(registrations))
+	for _, registration := range registrations {
+		if registration.path == "" {
+			return errors.New("empty path for a handler")
+		}
+		if paths[registration.path] {
+			return fmt.Errorf("several handlers for the path %s", registration.path)
+		}
+		paths[registration.path] = true
+		if registration.handler == nil && registration.reviewer == nil {
+			return fmt.Errorf("missing handler for the path %s", registration.path)
+		}
+	}
+	for _, registration := range registrations {
+		handler := registration.handler
+		if registration.reviewer != nil {
+			handlerOptions := []admissionreview.HandlerOption{admissionreview.WithTimeout(server.config.ReviewTimeout), admissionreview.WithLogger(server.logger)}
+			handlerOptions = append(handlerOptions, server.handlerOptions...)
+			handlerOptions = append(handlerOptions, registration.handlerOptions...)
+			handler = admissionreview.NewHandler(registration.reviewer, handlerOptions...)
+		}
+		server.mux.Handle(registration.path, handler)
+	}
+	return nil
+}
+
+// CABundle returns the PEM encoded CA bundle if a self-signed certificate is used, see Config.SelfSignedDNSNames. It has to be set as
+// caBundle of the WebhookConfiguration, e.g. via SetMutatingCABundle. Returns nil otherwise.
+// The CA bundle changes if the CA is renewed 30 days before it expires after 10 years, which is logged as error. The WebhookConfiguration
+// has to be updated with the new CA bundle in this case, otherwise admission requests fail.
+func (server *Server) CABundle() []byte {
+	if caBundle := server.caBundle.Load(); caBundle != nil {
+		return *caBundle
+	}
+	return nil
+}
+
+// Run listens on the configured port and serves until ctx is done or SIGTERM or SIGINT is received, see Serve.
+func (server *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(server.config.Port))
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	return server.Serve(ctx, listener)
+}
+
+// Serve serves on the listener until ctx is done. Afterwards the readiness endpoint fails for the shutdown delay before the server
+// is shut down gracefully. Returns nil if the server has been shut down gracefully. TLS is used if a certificate is configured,
+// the certificate is reloaded when the files change, see CertificateLoader.
+func (server *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{
+		Handler:           server.mux,
+		ReadHeaderTimeout: server.config.ReadHeaderTimeout,
+		ReadTimeout:       server.config.ReadTimeout,
+		WriteTimeout:      server.config.WriteTimeout,
+		IdleTimeout:       server.config.IdleTimeout,
+	}
+	if server.certificateLoader != nil {
+		httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: server.certificateLoader.GetCertificate,
+		}
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		if server.config.CertReloadInterval > 0 {
+			go server.certificateLoader.Watch(watchCtx, server.config.CertReloadInterval)
+		}
+		if len(server.config.SelfSignedDNSNames) > 0 && server.config.SelfSignedRenewInterval > 0 {
+			go server.renewSelfSigned(watchCtx, server.config.SelfSignedRenewInterval)
+		}
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		if server.certificateLoader != nil {
+			server.logger.Log(admissionreview.LogLevelInfo, "Serving HTTPS", "address", listener.Addr().String())
+			errChan <- httpServer.ServeTLS(listener, "", "")
+			return
+		}
+		server.logger.Log(admissionreview.LogLevelInfo, "Serving HTTP", "address", listener.Addr().String())
+		errChan <- httpServer.Serve(listener)
+	}()
+	server.ready.Store(true)
+
+	select {
+	case err := <-errChan:
+		server.ready.Store(false)
+		return err
+	case <-ctx.Done():
+	}
+	server.ready.Store(false)
+	server.logger.Log(admissionreview.LogLevelInfo, "Shutting down", "delay", server.config.ShutdownDelay)
+	select {
+	case err := <-errChan:
+		return err
+	case <-time.After(server.config.ShutdownDelay):
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), server.config.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errChan; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	server.logger.Log(admissionreview.LogLevelInfo, "Shutdown complete")
+	return nil
+}
+
+// renewSelfSigned renews the self-signed certificate every interval if it is about to expire until ctx is done, see EnsureSelfSignedCertificate.
+// The renewed certificate is loaded immediately. Failed renewals are logged and retried in the next interval.
+func (server *Server) renewSelfSigned(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			selfSigned, err := EnsureSelfSignedCertificate(server.config.SelfSignedDir, server.config.SelfSignedDNSNames)
+			if err != nil {
+				server.logger.Log(admissionreview.LogLevelWarn, "Failed to renew the self-signed certificate", "error", err)
+				continue
+			}
+			server.caBundle.Store(&selfSigned.CABundle)
+			if selfSigned.CAGenerated {
+				server.logger.Log(admissionreview.LogLevelError, "Renewed the self-signed CA, admission requests fail until the CA bundle of the WebhookConfiguration is updated")
+			}
+			reloaded, err := server.certificateLoader.Reload()
+			if err != nil {
+				server.logger.Log(admissionreview.LogLevelWarn, "Failed to load the renewed self-signed certificate", "error", err)
+				continue
+			}
+			if reloaded {
+				server.logger.Log(admissionreview.LogLevelInfo, "Renewed the self-signed certificate")
+			}
+		}
+	}
+}
+
+// handleLiveness always returns HTTP 200 for GET requests.
+func (server *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleReadiness returns HTTP 200 for GET requests while the server is serving and HTTP 503 during the shutdown.
+func (server *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !server.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,87 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ngergs/k8s-adm-ctrl/admissionreview"
+	"github.com/ngergs/k8s-adm-ctrl/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+var allowingReviewer = admissionreview.ReviewFunc(func(arRequest *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{UID: arRequest.UID, Allowed: true}
+})
+
+func TestServerLifecycle(t *testing.T) {
+	config := server.DefaultConfig()
+	config.ShutdownDelay = 200 * time.Millisecond
+	srv, err := server.New(config, server.WithReviewer("/validate", allowingReviewer), server.WithLogger(admissionreview.NopLogger))
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	baseUrl := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.Serve(ctx, listener)
+	}()
+
+	require.Eventually(t, func() bool { return getStatus(t, baseUrl+"/ready") == http.StatusOK }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, getStatus(t, baseUrl+"/health"))
+
+	body, err := json.Marshal(&admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{UID: "123"}})
+	require.NoError(t, err)
+	resp, err := http.Post(baseUrl+"/validate", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var arReview admissionv1.AdmissionReview
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&arReview))
+	assert.True(t, arReview.Response.Allowed)
+
+	// the readiness endpoint fails during the shutdown delay while the server is still serving
+	cancel()
+	require.Eventually(t, func() bool { return getStatus(t, baseUrl+"/ready") == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, getStatus(t, baseUrl+"/health"))
+	select {
+	case err := <-errChan:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestServerInvalidConfig(t *testing.T) {
+	config := server.DefaultConfig()
+	config.TlsCrt = "tls.crt"
+	_, err := server.New(config)
+	assert.Error(t, err)
+}
+
+// getStatus sends a GET request to the url and returns the HTTP status code
+func getStatus(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServerDuplicatePaths(t *testing.T) {
+	for _, opts := range [][]server.Option{
+		{server.WithHandler("/health", http.NotFoundHandler())},
+		{server.WithReviewer("/ready", allowingReviewer)},
+		{server.WithReviewer("/validate", allowingReviewer), server.WithHandler("/validate", http.NotFoundHandler())},
+		{server.WithReviewer("", allowingReviewer)},
+	} {
+		_, err := server.New(server.DefaultConfig(), append(opts, server.WithLogger(admissionreview.NopLogger))...)
+		assert.Error(t, err)
+	}
+}